@@ -0,0 +1,307 @@
+package core
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/averseabfun/flux/impl"
+	"github.com/averseabfun/flux/interfaces"
+	"github.com/averseabfun/flux/types"
+)
+
+var EditorBackgroundColor types.PaletteIndex = 0
+var EditorGridColor types.PaletteIndex = 2
+var EditorSelectionColor types.PaletteIndex = 2
+var EditorScale uint32 = 4
+var EditorGridSizes = []uint32{1, 2, 4, 8}
+var EditorMaxUndo = 100
+
+type editorDrag uint8
+
+const (
+	editorDragNone = editorDrag(iota)
+	editorDragDraw
+	editorDragMove
+	editorDragResize
+)
+
+// editor is a top-down map editor for the world Main is running. Left-drag
+// on empty space draws a wall, left-drag a wall to move it or its
+// bottom-right corner to resize it, and right-click a wall to cycle its
+// color (shift-right-click to cycle back). Delete removes the selected wall,
+// G changes the grid, the arrow keys pan, Ctrl+Z and Ctrl+Y undo and redo,
+// and Ctrl+S saves to WorldPath. Ctrl+E toggles the editor.
+type editor struct {
+	active   bool
+	world    *types.WorldWolf
+	grid     int
+	pan      types.Point
+	color    types.PaletteIndex
+	selected types.ObjectID
+	hasSel   bool
+
+	drag      editorDrag
+	dragStart types.Point
+	dragObj   types.ObjectID
+
+	undo [][]types.RectWolf
+	redo [][]types.RectWolf
+}
+
+func newEditor(world *types.WorldWolf) *editor {
+	return &editor{world: world, color: 1}
+}
+
+func (ed *editor) gridSize() uint32 {
+	return EditorGridSizes[ed.grid%len(EditorGridSizes)]
+}
+
+func (ed *editor) toWorld(posX float64, posY float64) types.Point {
+	return types.Point{X: uint32(max(posX, 0))/EditorScale + ed.pan.X, Y: uint32(max(posY, 0))/EditorScale + ed.pan.Y}
+}
+
+func (ed *editor) snap(point types.Point) types.Point {
+	var grid = ed.gridSize()
+	return types.Point{X: (point.X + grid/2) / grid * grid, Y: (point.Y + grid/2) / grid * grid}
+}
+
+func (ed *editor) objectAt(point types.Point) (types.ObjectID, bool) {
+	var ids = slices.Sorted(maps.Keys(ed.world.Objects))
+	for _, id := range slices.Backward(ids) {
+		var object = ed.world.Objects[id]
+		if point.X >= object.Start.X && point.Y >= object.Start.Y && point.X <= object.End.X && point.Y <= object.End.Y {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+func (ed *editor) snapshot() []types.RectWolf {
+	var out = make([]types.RectWolf, 0, len(ed.world.Objects))
+	for _, id := range slices.Sorted(maps.Keys(ed.world.Objects)) {
+		var object = *ed.world.Objects[id]
+		object.SideSamplers = maps.Clone(object.SideSamplers)
+		out = append(out, object)
+	}
+	return out
+}
+
+func (ed *editor) restore(snapshot []types.RectWolf) {
+	clear(ed.world.Objects)
+	for _, object := range snapshot {
+		var copied = object
+		copied.SideSamplers = maps.Clone(object.SideSamplers)
+		copied.World = ed.world
+		ed.world.Objects[copied.ID] = &copied
+	}
+	if _, ok := ed.world.Objects[ed.selected]; !ok {
+		ed.hasSel = false
+	}
+}
+
+// checkpoint records the world for undo; call it before every change.
+func (ed *editor) checkpoint() {
+	ed.undo = append(ed.undo, ed.snapshot())
+	if len(ed.undo) > EditorMaxUndo {
+		ed.undo = slices.Delete(ed.undo, 0, len(ed.undo)-EditorMaxUndo)
+	}
+	ed.redo = nil
+}
+
+func (ed *editor) Undo() {
+	if len(ed.undo) == 0 {
+		return
+	}
+	ed.redo = append(ed.redo, ed.snapshot())
+	ed.restore(ed.undo[len(ed.undo)-1])
+	ed.undo = ed.undo[:len(ed.undo)-1]
+}
+
+func (ed *editor) Redo() {
+	if len(ed.redo) == 0 {
+		return
+	}
+	ed.undo = append(ed.undo, ed.snapshot())
+	ed.restore(ed.redo[len(ed.redo)-1])
+	ed.redo = ed.redo[:len(ed.redo)-1]
+}
+
+func (ed *editor) Save() error {
+	return impl.SaveWolfWorld(WorldPath, *ed.world)
+}
+
+func (ed *editor) GrabKey(key interfaces.Key, scancode int, action interfaces.Action, mods interfaces.ModifierKey) bool {
+	if key == interfaces.KeyE && mods == interfaces.ModControl {
+		if action == interfaces.Press {
+			ed.active = !ed.active
+			ed.drag = editorDragNone
+		}
+		return true
+	}
+	if !ed.active {
+		return false
+	}
+	if action == interfaces.Release {
+		return false
+	}
+	switch {
+	case key == interfaces.KeyZ && mods == interfaces.ModControl:
+		ed.Undo()
+	case (key == interfaces.KeyY && mods == interfaces.ModControl) || (key == interfaces.KeyZ && mods == interfaces.ModControl|interfaces.ModShift):
+		ed.Redo()
+	case key == interfaces.KeyS && mods == interfaces.ModControl:
+		if err := ed.Save(); err != nil {
+			fmt.Printf("Saving %s failed: %s\n", WorldPath, err)
+		} else {
+			fmt.Printf("Saved %s\n", WorldPath)
+		}
+	case (key == interfaces.KeyDelete || key == interfaces.KeyBackspace) && ed.hasSel:
+		ed.checkpoint()
+		delete(ed.world.Objects, ed.selected)
+		ed.hasSel = false
+	case key == interfaces.KeyG && mods == 0:
+		ed.grid = (ed.grid + 1) % len(EditorGridSizes)
+	case key == interfaces.KeyLeft:
+		ed.pan.X -= min(ed.gridSize(), ed.pan.X)
+	case key == interfaces.KeyRight:
+		ed.pan.X += ed.gridSize()
+	case key == interfaces.KeyUp:
+		ed.pan.Y -= min(ed.gridSize(), ed.pan.Y)
+	case key == interfaces.KeyDown:
+		ed.pan.Y += ed.gridSize()
+	default:
+		// Leave debug and recording keys to the grabbers below.
+		return mods&interfaces.ModControl == 0
+	}
+	return true
+}
+
+func (ed *editor) GrabMouse(button interfaces.MouseButton, action interfaces.Action, mods interfaces.ModifierKey, posX float64, posY float64) bool {
+	if !ed.active {
+		return false
+	}
+	var point = ed.toWorld(posX, posY)
+	switch {
+	case button == interfaces.MouseButton1 && action == interfaces.Press:
+		ed.startDrag(point)
+	case button == interfaces.MouseButton1 && action == interfaces.Release:
+		ed.finishDrag(point)
+	case button == interfaces.MouseButton2 && action == interfaces.Press:
+		if id, ok := ed.objectAt(point); ok {
+			ed.checkpoint()
+			if mods&interfaces.ModShift != 0 {
+				ed.world.Objects[id].Color--
+			} else {
+				ed.world.Objects[id].Color++
+			}
+			ed.color = ed.world.Objects[id].Color
+			ed.selected, ed.hasSel = id, true
+		}
+	}
+	return true
+}
+
+func (ed *editor) startDrag(point types.Point) {
+	ed.dragStart = point
+	var id, ok = ed.objectAt(point)
+	if !ok {
+		ed.hasSel = false
+		ed.drag = editorDragDraw
+		return
+	}
+	ed.selected, ed.hasSel, ed.dragObj = id, true, id
+	var object = ed.world.Objects[id]
+	ed.color = object.Color
+	if object.End.X+1-point.X <= ed.gridSize() && object.End.Y+1-point.Y <= ed.gridSize() {
+		ed.drag = editorDragResize
+	} else {
+		ed.drag = editorDragMove
+	}
+}
+
+func (ed *editor) finishDrag(point types.Point) {
+	var drag = ed.drag
+	ed.drag = editorDragNone
+	var start, end = ed.snap(ed.dragStart), ed.snap(point)
+	switch drag {
+	case editorDragDraw:
+		var rect = &types.RectWolf{
+			Start: types.Point{X: min(start.X, end.X), Y: min(start.Y, end.Y)},
+			End:   types.Point{X: max(start.X, end.X), Y: max(start.Y, end.Y)},
+			Color: ed.color,
+			World: ed.world,
+		}
+		if rect.End.X == rect.Start.X {
+			rect.End.X += ed.gridSize()
+		}
+		if rect.End.Y == rect.Start.Y {
+			rect.End.Y += ed.gridSize()
+		}
+		rect.End = rect.End.Sub(types.Point{X: 1, Y: 1})
+		for _, id := range slices.Sorted(maps.Keys(ed.world.Objects)) {
+			rect.ID = max(rect.ID, id)
+		}
+		rect.ID++
+		ed.checkpoint()
+		ed.world.Objects[rect.ID] = rect
+		ed.selected, ed.hasSel = rect.ID, true
+	case editorDragMove:
+		var object, ok = ed.world.Objects[ed.dragObj]
+		if !ok || start == end {
+			return
+		}
+		ed.checkpoint()
+		var dx = max(int64(end.X)-int64(start.X), -int64(object.Start.X))
+		var dy = max(int64(end.Y)-int64(start.Y), -int64(object.Start.Y))
+		object.Start = types.Point{X: uint32(int64(object.Start.X) + dx), Y: uint32(int64(object.Start.Y) + dy)}
+		object.End = types.Point{X: uint32(int64(object.End.X) + dx), Y: uint32(int64(object.End.Y) + dy)}
+	case editorDragResize:
+		var object, ok = ed.world.Objects[ed.dragObj]
+		if !ok {
+			return
+		}
+		ed.checkpoint()
+		object.End = types.Point{X: max(end.X, object.Start.X+1) - 1, Y: max(end.Y, object.Start.Y+1) - 1}
+	}
+}
+
+func (ed *editor) toScreen(point types.Point) (int64, int64) {
+	return (int64(point.X) - int64(ed.pan.X)) * int64(EditorScale), (int64(point.Y) - int64(ed.pan.Y)) * int64(EditorScale)
+}
+
+func (ed *editor) Render() {
+	var size = rawRenderer.GetSize()
+	rawRenderer.FillBack(EditorBackgroundColor)
+
+	var step = ed.gridSize() * EditorScale
+	for y := (step - ed.pan.Y%ed.gridSize()*EditorScale) % step; y < size.Y; y += step {
+		for x := (step - ed.pan.X%ed.gridSize()*EditorScale) % step; x < size.X; x += step {
+			rawRenderer.DrawBackPixel(x, y, EditorGridColor)
+		}
+	}
+
+	var sampler = &impl.FlatSampler{}
+	for _, id := range slices.Sorted(maps.Keys(ed.world.Objects)) {
+		var object = ed.world.Objects[id]
+		var x0, y0 = ed.toScreen(object.Start)
+		var x1, y1 = ed.toScreen(object.End.Add(types.Point{X: 1, Y: 1}))
+		x0, y0 = max(x0, 0), max(y0, 0)
+		x1, y1 = min(x1-1, int64(size.X)-1), min(y1-1, int64(size.Y)-1)
+		if x1 < x0 || y1 < y0 {
+			continue
+		}
+		var points = []types.Point{
+			{X: uint32(x0), Y: uint32(y0)}, {X: uint32(x1), Y: uint32(y0)},
+			{X: uint32(x1), Y: uint32(y1)}, {X: uint32(x0), Y: uint32(y1)},
+			{X: uint32(x0), Y: uint32(y0)},
+		}
+		sampler.SetColor(object.Color)
+		polyRenderer.DrawPoly(&types.Poly{Points: points, SamplerPoints: types.MakePolySamplerPoints(points)}, sampler)
+		if ed.hasSel && id == ed.selected {
+			for i := 1; i < len(points); i++ {
+				lr.DrawLine(points[i-1], points[i], EditorSelectionColor)
+			}
+		}
+	}
+}
@@ -1,13 +1,13 @@
 package core
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/averseabfun/flux/impl"
 	"github.com/averseabfun/flux/interfaces"
 	"github.com/averseabfun/flux/types"
-	"github.com/go-gl/glfw/v3.3/glfw"
 )
 
 var rawRenderer interfaces.RawRenderer
@@ -16,6 +16,20 @@ var mouseProvider interfaces.MouseProvider
 var lr interfaces.LineRenderer
 var polyRenderer interfaces.PolyRenderer
 var wolfRenderer interfaces.WolfRenderer
+var automap *impl.Automap
+
+// SelectedWolfRenderer is the Wolf renderer Init sets up; if nil, Init uses the ray marcher.
+var SelectedWolfRenderer interfaces.WolfRenderer
+
+// WorldPath is the map Main loads, in any format registered with impl.RegisterWolfCodec.
+var WorldPath = "./testWorld.txt"
+
+// PalettePath, if set, is a palette file Init applies in place of the built-in colors.
+var PalettePath = ""
+
+// PaletteEffects, if set, is updated and applied to the renderer every frame.
+var PaletteEffects *impl.PaletteEffects
+var recorder = &impl.FrameRecorder{Format: impl.RecordGIF, Path: "recording.gif"}
 
 func Init(backend interfaces.RawRenderer, provider interfaces.KeyProvider, mProvider interfaces.MouseProvider, windowTitle string) {
 	if err := backend.InitRenderer(windowTitle, 320, 200); err != nil {
@@ -29,9 +43,25 @@ func Init(backend interfaces.RawRenderer, provider interfaces.KeyProvider, mProv
 	polyRenderer = &impl.PolyRenderer{}
 	polyRenderer.SetParent(rawRenderer)
 	polyRenderer.SetLineRenderer(lr)
-	wolfRenderer = &impl.WolfRayMarcher{}
+	wolfRenderer = SelectedWolfRenderer
+	if wolfRenderer == nil {
+		wolfRenderer = &impl.WolfRayMarcher{}
+	}
 	wolfRenderer.SetParent(rawRenderer)
+	automap = &impl.Automap{WallColor: 1, PlayerColor: 2, Follow: true}
+	automap.SetParent(rawRenderer)
+	automap.SetLineRenderer(lr)
 
+	if PalettePath != "" {
+		var palette, err = impl.LoadPalette(PalettePath)
+		if err != nil {
+			panic(err)
+		}
+		if err = impl.ApplyPalette(rawRenderer, palette); err != nil {
+			panic(err)
+		}
+		return
+	}
 	rawRenderer.SetPaletteColor(0, types.FromRGBNoErr(0, 0, 0))
 	rawRenderer.SetPaletteColor(1, types.FromRGBNoErr(63, 0, 0))
 	rawRenderer.SetPaletteColor(2, types.FromRGBNoErr(0, 63, 0))
@@ -45,18 +75,67 @@ func Main() {
 	var overallNumSamples = 0
 	var debug = false
 	var position = false
-	keyProvider.PushGrabber(&impl.DebugGrabber{ValueToChange: &debug, WhichAction: glfw.Press, Key: glfw.KeyD, Mods: glfw.ModControl})
-	mouseProvider.PushMouseGrabber(&impl.DebugGrabber{ValueToChange: &position, MouseAction: glfw.Press, MouseMods: 0, MouseButton: glfw.MouseButton1})
-	var world, err = impl.ImportWolfWorld("./testWorld.txt")
+	keyProvider.PushGrabber(&impl.DebugGrabber{ValueToChange: &debug, WhichAction: interfaces.Press, Key: interfaces.KeyD, Mods: interfaces.ModControl})
+	mouseProvider.PushMouseGrabber(&impl.DebugGrabber{ValueToChange: &position, MouseAction: interfaces.Press, MouseMods: 0, MouseButton: interfaces.MouseButton1})
+	var readBack, canReadBack = rawRenderer.(interfaces.ReadBackRenderer)
+	if canReadBack {
+		keyProvider.PushGrabber(&impl.RecorderGrabber{Recorder: recorder, Key: interfaces.KeyR, Mods: interfaces.ModControl})
+	}
+	var world, err = impl.LoadWolfWorld(WorldPath)
 	if err != nil {
 		panic(err)
 	}
 	fmt.Println(world.Objects[1])
-	var rotation types.Degree = 270
+	for index, color := range world.Palette {
+		rawRenderer.SetPaletteColor(index, color)
+	}
+	var player = &impl.PlayerController{Position: world.PlayerStart, Heading: world.PlayerHeading}
+	keyProvider.PushGrabber(player)
+	keyProvider.PushGrabberAt(automap, 0)
+	var mapEditor = newEditor(&world)
+	keyProvider.PushGrabberAt(mapEditor, 0)
+	mouseProvider.PushMouseGrabberAt(mapEditor, 0)
+	var preciseRenderer, canRenderPrecise = wolfRenderer.(interfaces.PreciseWolfRenderer)
+	var hitRecorder, canRecordHits = wolfRenderer.(impl.WolfHitRecorder)
+	var lastTick = time.Now()
 	for !rawRenderer.ShouldQuit() {
 		var t1 = time.Now()
+		var elapsed = t1.Sub(lastTick)
+		lastTick = t1
+		if PaletteEffects != nil {
+			PaletteEffects.Update(elapsed)
+			if err := PaletteEffects.Apply(rawRenderer); err != nil {
+				panic(err)
+			}
+		}
 		rawRenderer.TickRenderer()
-		wolfRenderer.RenderWorld(world, types.Point{X: 0, Y: 0}, rotation)
+		if canReadBack && recorder.IsRecording() {
+			if err := recorder.CaptureFrame(readBack, elapsed); errors.Is(err, impl.ErrRecordingLimit) {
+				fmt.Printf("Stopped recording: %s\n", err)
+			} else if err != nil {
+				fmt.Printf("Recording failed: %s\n", err)
+				recorder.Stop()
+			}
+		}
+		if mapEditor.active {
+			mapEditor.Render()
+		} else {
+			player.Update(world, elapsed)
+			if automap.Mode != impl.AutomapFull {
+				rawRenderer.FillBack(0)
+				if canRenderPrecise {
+					preciseRenderer.RenderWorldPrecise(world, player.Position, player.Heading)
+				} else {
+					wolfRenderer.RenderWorld(world, types.Point{X: uint32(max(player.Position.X, 0)), Y: uint32(max(player.Position.Y, 0))}, player.Heading)
+				}
+				if canRecordHits {
+					automap.RecordHits(hitRecorder.LastHits())
+				}
+			}
+			if automap.Mode != impl.AutomapOff {
+				automap.RenderAutomap(world, player.Position, player.Heading)
+			}
+		}
 		var t2 = time.Now()
 		renderTime += t2.Sub(t1)
 		numSamples++
@@ -0,0 +1,224 @@
+package impl
+
+import (
+	"math"
+
+	"github.com/averseabfun/flux/interfaces"
+	"github.com/averseabfun/flux/types"
+)
+
+var AutomapDefaultZoom float64 = 4
+var AutomapMinZoom float64 = 0.5
+var AutomapMaxZoom float64 = 32
+var AutomapPanStep float64 = 4
+var AutomapArrowSize float64 = 6
+
+type AutomapMode uint8
+
+const (
+	AutomapOff = AutomapMode(iota)
+	AutomapOverlay
+	AutomapFull
+)
+
+// Automap draws the world's walls from above as lines, centered on the
+// player in follow mode or on Pan otherwise. As a KeyGrabber, Tab cycles
+// between off, overlay and full screen; while shown, = and - zoom, F toggles
+// follow mode, V toggles showing only walls the player has seen, and the
+// arrow keys pan when not following.
+type Automap struct {
+	parent       interfaces.RawRenderer
+	lineRenderer interfaces.LineRenderer
+
+	Mode     AutomapMode
+	Zoom     float64
+	Pan      types.SamplerPoint
+	Follow   bool
+	SeenOnly bool
+
+	BackgroundColor types.PaletteIndex
+	WallColor       types.PaletteIndex
+	PlayerColor     types.PaletteIndex
+
+	seen map[types.ObjectID]bool
+}
+
+func (am *Automap) Parent() interfaces.RawRenderer {
+	return am.parent
+}
+
+func (am *Automap) SetParent(rr interfaces.RawRenderer) {
+	am.parent = rr
+}
+
+func (am *Automap) CanUseCurrentRawRenderer() bool {
+	return true
+}
+
+func (am *Automap) GetLineRenderer() interfaces.LineRenderer {
+	if am.lineRenderer == nil {
+		am.lineRenderer = &BresenhamRenderer{}
+	}
+	am.lineRenderer.SetParent(am.Parent())
+	return am.lineRenderer
+}
+
+func (am *Automap) SetLineRenderer(lr interfaces.LineRenderer) {
+	am.lineRenderer = lr
+}
+
+// RecordHits marks every wall struck in hits as seen.
+func (am *Automap) RecordHits(hits []WolfHit) {
+	if am.seen == nil {
+		am.seen = make(map[types.ObjectID]bool)
+	}
+	for _, hit := range hits {
+		if hit.Object != nil {
+			am.seen[hit.Object.ID] = true
+		}
+	}
+}
+
+func (am *Automap) HasSeen(id types.ObjectID) bool {
+	return am.seen[id]
+}
+
+func (am *Automap) ClearSeen() {
+	clear(am.seen)
+}
+
+func (am *Automap) zoom() float64 {
+	if am.Zoom == 0 {
+		return AutomapDefaultZoom
+	}
+	return am.Zoom
+}
+
+func (am *Automap) GrabKey(key interfaces.Key, scancode int, action interfaces.Action, mods interfaces.ModifierKey) bool {
+	if key == interfaces.KeyTab && mods == 0 {
+		if action == interfaces.Press {
+			am.Mode = (am.Mode + 1) % (AutomapFull + 1)
+		}
+		return true
+	}
+	if am.Mode == AutomapOff || mods != 0 {
+		return false
+	}
+	var pressed = action != interfaces.Release
+	switch key {
+	case interfaces.KeyEqual, interfaces.KeyKPAdd:
+		if pressed {
+			am.Zoom = min(am.zoom()*1.25, AutomapMaxZoom)
+		}
+	case interfaces.KeyMinus, interfaces.KeyKPSubtract:
+		if pressed {
+			am.Zoom = max(am.zoom()/1.25, AutomapMinZoom)
+		}
+	case interfaces.KeyF:
+		if action == interfaces.Press {
+			am.Follow = !am.Follow
+		}
+	case interfaces.KeyV:
+		if action == interfaces.Press {
+			am.SeenOnly = !am.SeenOnly
+		}
+	case interfaces.KeyLeft, interfaces.KeyRight, interfaces.KeyUp, interfaces.KeyDown:
+		if am.Follow {
+			return false
+		}
+		if pressed {
+			var step = AutomapPanStep / am.zoom() * AutomapDefaultZoom
+			switch key {
+			case interfaces.KeyLeft:
+				am.Pan.X -= step
+			case interfaces.KeyRight:
+				am.Pan.X += step
+			case interfaces.KeyUp:
+				am.Pan.Y -= step
+			case interfaces.KeyDown:
+				am.Pan.Y += step
+			}
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// clipLine clips a line to the rectangle [0, width) x [0, height) with the
+// Liang-Barsky algorithm, reporting false if nothing of it is left.
+func clipLine(x0 float64, y0 float64, x1 float64, y1 float64, width float64, height float64) (float64, float64, float64, float64, bool) {
+	var t0, t1 = 0.0, 1.0
+	var dx, dy = x1 - x0, y1 - y0
+	for _, edge := range [4][2]float64{{-dx, x0}, {dx, width - 1 - x0}, {-dy, y0}, {dy, height - 1 - y0}} {
+		var p, q = edge[0], edge[1]
+		if p == 0 {
+			if q < 0 {
+				return 0, 0, 0, 0, false
+			}
+			continue
+		}
+		var t = q / p
+		if p < 0 {
+			t0 = max(t0, t)
+		} else {
+			t1 = min(t1, t)
+		}
+		if t0 > t1 {
+			return 0, 0, 0, 0, false
+		}
+	}
+	return x0 + t0*dx, y0 + t0*dy, x0 + t1*dx, y0 + t1*dy, true
+}
+
+func (am *Automap) drawWorldLine(focus types.SamplerPoint, from types.SamplerPoint, to types.SamplerPoint, color types.PaletteIndex) {
+	var size = am.Parent().GetSize()
+	var zoom = am.zoom()
+	var centerX, centerY = float64(size.X) / 2, float64(size.Y) / 2
+	var x0, y0, x1, y1, ok = clipLine(
+		centerX+(from.X-focus.X)*zoom, centerY+(from.Y-focus.Y)*zoom,
+		centerX+(to.X-focus.X)*zoom, centerY+(to.Y-focus.Y)*zoom,
+		float64(size.X), float64(size.Y),
+	)
+	if !ok {
+		return
+	}
+	am.GetLineRenderer().DrawLine(
+		types.Point{X: uint32(math.Round(x0)), Y: uint32(math.Round(y0))},
+		types.Point{X: uint32(math.Round(x1)), Y: uint32(math.Round(y1))},
+		color,
+	)
+}
+
+func (am *Automap) RenderAutomap(world types.WorldWolf, playerPos types.SamplerPoint, playerHeading types.Degree) {
+	if am.Mode == AutomapFull {
+		am.Parent().FillBack(am.BackgroundColor)
+	}
+	var focus = am.Pan
+	if am.Follow {
+		focus = playerPos
+		am.Pan = playerPos
+	}
+	for _, id := range sortedIDs(world.Objects) {
+		if am.SeenOnly && !am.seen[id] {
+			continue
+		}
+		var object = world.Objects[id]
+		var x0, y0 = float64(object.Start.X), float64(object.Start.Y)
+		var x1, y1 = float64(object.End.X) + 1, float64(object.End.Y) + 1
+		var corners = [5]types.SamplerPoint{{X: x0, Y: y0}, {X: x1, Y: y0}, {X: x1, Y: y1}, {X: x0, Y: y1}, {X: x0, Y: y0}}
+		for i := 1; i < len(corners); i++ {
+			am.drawWorldLine(focus, corners[i-1], corners[i], am.WallColor)
+		}
+	}
+
+	var rads = float64(playerHeading.ToRadians())
+	var size = AutomapArrowSize / am.zoom()
+	var forward = types.SamplerPoint{X: math.Cos(rads) * size, Y: math.Sin(rads) * size}
+	var side = types.SamplerPoint{X: -forward.Y / 2, Y: forward.X / 2}
+	var tip = types.SamplerPoint{X: playerPos.X + forward.X, Y: playerPos.Y + forward.Y}
+	var tail = types.SamplerPoint{X: playerPos.X - forward.X, Y: playerPos.Y - forward.Y}
+	am.drawWorldLine(focus, tail, tip, am.PlayerColor)
+	am.drawWorldLine(focus, tip, types.SamplerPoint{X: playerPos.X + side.X, Y: playerPos.Y + side.Y}, am.PlayerColor)
+	am.drawWorldLine(focus, tip, types.SamplerPoint{X: playerPos.X - side.X, Y: playerPos.Y - side.Y}, am.PlayerColor)
+}
@@ -0,0 +1,155 @@
+package impl
+
+import (
+	"math"
+
+	"github.com/averseabfun/flux/types"
+)
+
+// BSPSplitCost is how many segs of imbalance between the two sides of a
+// partition are worth one extra split when picking a partition line.
+var BSPSplitCost = 8
+var BSPEpsilon = 1e-6
+
+type bspBuilder struct {
+	tree *types.BSPTree
+}
+
+// BuildBSP builds a node tree for the world. The result only depends on the
+// order of the world's linedefs, so the same world always gives the same tree.
+func BuildBSP(world *types.WorldSector) *types.BSPTree {
+	var segs = make([]types.Seg, 0, len(world.Linedefs)*2)
+	for i, line := range world.Linedefs {
+		var start, end = world.Vertices[line.Start], world.Vertices[line.End]
+		if start == end {
+			continue
+		}
+		segs = append(segs, types.Seg{Start: start, End: end, Linedef: i, Front: true})
+		if line.IsTwoSided() {
+			segs = append(segs, types.Seg{Start: end, End: start, Linedef: i, Front: false})
+		}
+	}
+	var builder = bspBuilder{tree: &types.BSPTree{Linedefs: len(world.Linedefs)}}
+	builder.tree.Root = builder.build(segs)
+	return builder.tree
+}
+
+// segSide is negative for points on the front (right) of the seg's line and
+// positive for points behind it.
+func segSide(seg types.Seg, point types.Vertex) float64 {
+	var side = (seg.End.X-seg.Start.X)*(point.Y-seg.Start.Y) - (seg.End.Y-seg.Start.Y)*(point.X-seg.Start.X)
+	if math.Abs(side) < BSPEpsilon*math.Hypot(seg.End.X-seg.Start.X, seg.End.Y-seg.Start.Y) {
+		return 0
+	}
+	return side
+}
+
+type segPlacement int
+
+const (
+	segInFront segPlacement = iota
+	segBehind
+	segSpanning
+)
+
+// placeSeg says which side of splitter's line seg goes on. Segs on the line
+// go in front if they face the same way as splitter and behind otherwise.
+// The sides of seg's ends are returned for splitting spanning segs.
+func placeSeg(splitter types.Seg, seg types.Seg) (segPlacement, float64, float64) {
+	var startSide, endSide = segSide(splitter, seg.Start), segSide(splitter, seg.End)
+	switch {
+	case startSide == 0 && endSide == 0:
+		var sameWay = (seg.End.X-seg.Start.X)*(splitter.End.X-splitter.Start.X)+(seg.End.Y-seg.Start.Y)*(splitter.End.Y-splitter.Start.Y) > 0
+		if sameWay {
+			return segInFront, startSide, endSide
+		}
+		return segBehind, startSide, endSide
+	case startSide <= 0 && endSide <= 0:
+		return segInFront, startSide, endSide
+	case startSide >= 0 && endSide >= 0:
+		return segBehind, startSide, endSide
+	default:
+		return segSpanning, startSide, endSide
+	}
+}
+
+func (bb *bspBuilder) build(segs []types.Seg) types.BSPChild {
+	var splitter, ok = bb.chooseSplitter(segs)
+	if !ok {
+		bb.tree.Subsectors = append(bb.tree.Subsectors, types.Subsector{FirstSeg: len(bb.tree.Segs), NumSegs: len(segs)})
+		bb.tree.Segs = append(bb.tree.Segs, segs...)
+		return types.SubsectorChild(len(bb.tree.Subsectors) - 1)
+	}
+
+	var front, back []types.Seg
+	for _, seg := range segs {
+		var placement, startSide, endSide = placeSeg(splitter, seg)
+		switch placement {
+		case segInFront:
+			front = append(front, seg)
+		case segBehind:
+			back = append(back, seg)
+		default:
+			var first, second = splitSeg(seg, startSide/(startSide-endSide))
+			if startSide < 0 {
+				front, back = append(front, first), append(back, second)
+			} else {
+				back, front = append(back, first), append(front, second)
+			}
+		}
+	}
+
+	var index = len(bb.tree.Nodes)
+	bb.tree.Nodes = append(bb.tree.Nodes, types.BSPNode{
+		X:  splitter.Start.X,
+		Y:  splitter.Start.Y,
+		DX: splitter.End.X - splitter.Start.X,
+		DY: splitter.End.Y - splitter.Start.Y,
+	})
+	var frontChild = bb.build(front)
+	var backChild = bb.build(back)
+	bb.tree.Nodes[index].Front, bb.tree.Nodes[index].Back = frontChild, backChild
+	return types.NodeChild(index)
+}
+
+func splitSeg(seg types.Seg, t float64) (types.Seg, types.Seg) {
+	var at = types.Vertex{X: seg.Start.X + (seg.End.X-seg.Start.X)*t, Y: seg.Start.Y + (seg.End.Y-seg.Start.Y)*t}
+	var first, second = seg, seg
+	first.End = at
+	second.Start = at
+	second.Offset = seg.Offset + math.Hypot(at.X-seg.Start.X, at.Y-seg.Start.Y)
+	return first, second
+}
+
+// chooseSplitter picks the seg whose line splits the fewest other segs while
+// keeping the two sides balanced, breaking ties by taking the earliest seg.
+// It returns false if the segs already form a convex subsector.
+func (bb *bspBuilder) chooseSplitter(segs []types.Seg) (types.Seg, bool) {
+	var best types.Seg
+	var bestScore = math.MaxInt
+	for _, candidate := range segs {
+		var splits, fronts, backs int
+		for _, seg := range segs {
+			var placement, _, _ = placeSeg(candidate, seg)
+			switch placement {
+			case segInFront:
+				fronts++
+			case segBehind:
+				backs++
+			default:
+				splits++
+			}
+		}
+		if splits == 0 && backs == 0 {
+			continue
+		}
+		var balance = fronts - backs
+		if balance < 0 {
+			balance = -balance
+		}
+		if score := splits*BSPSplitCost + balance; score < bestScore {
+			best, bestScore = candidate, score
+		}
+	}
+	return best, bestScore != math.MaxInt
+}
@@ -0,0 +1,157 @@
+package impl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/averseabfun/flux/types"
+)
+
+// BSP files are little-endian: the magic, a uint16 version, the number of
+// linedefs in the world the tree was built for, then the segs, subsectors
+// and nodes, each list prefixed by its length, and finally the root.
+
+var bspMagic = []byte("FLXN")
+
+const BSPVersion = 1
+
+var ErrStaleBSP = errors.New("node tree was built for a different world")
+
+func WriteBSP(w io.Writer, tree *types.BSPTree) error {
+	var bw = &binaryWriter{w: bufio.NewWriter(w)}
+	bw.write(bspMagic)
+	bw.write(uint16(BSPVersion))
+	bw.write(uint32(tree.Linedefs))
+	bw.write(uint32(len(tree.Segs)))
+	for _, seg := range tree.Segs {
+		bw.write([5]float64{seg.Start.X, seg.Start.Y, seg.End.X, seg.End.Y, seg.Offset})
+		bw.write(uint32(seg.Linedef))
+		bw.write(seg.Front)
+	}
+	bw.write(uint32(len(tree.Subsectors)))
+	for _, subsector := range tree.Subsectors {
+		bw.write([2]uint32{uint32(subsector.FirstSeg), uint32(subsector.NumSegs)})
+	}
+	bw.write(uint32(len(tree.Nodes)))
+	for _, node := range tree.Nodes {
+		bw.write([4]float64{node.X, node.Y, node.DX, node.DY})
+		bw.write([2]int32{int32(node.Front), int32(node.Back)})
+	}
+	bw.write(int32(tree.Root))
+	if bw.err != nil {
+		return bw.err
+	}
+	return bw.w.Flush()
+}
+
+// ReadBSP reads a tree written by WriteBSP and checks it against world.
+func ReadBSP(r io.Reader, world *types.WorldSector) (*types.BSPTree, error) {
+	var br = &binaryReader{r: bufio.NewReader(r)}
+	var magic = make([]byte, len(bspMagic))
+	var version uint16
+	var linedefs uint32
+	br.read(magic)
+	br.read(&version)
+	br.read(&linedefs)
+	if br.err != nil {
+		return nil, br.err
+	}
+	if string(magic) != string(bspMagic) {
+		return nil, fmt.Errorf("%w: bad magic %q", ErrUnknownWorldFormat, magic)
+	}
+	if version != BSPVersion {
+		return nil, fmt.Errorf("%w %d (expected %d)", ErrUnsupportedMapVersion, version, BSPVersion)
+	}
+	if int(linedefs) != len(world.Linedefs) {
+		return nil, fmt.Errorf("%w: it has %d linedefs, the world has %d", ErrStaleBSP, linedefs, len(world.Linedefs))
+	}
+	var out = &types.BSPTree{Linedefs: int(linedefs)}
+
+	var count uint32
+	br.read(&count)
+	for i := 0; i < int(count) && br.err == nil; i++ {
+		var values [5]float64
+		var linedef uint32
+		var seg types.Seg
+		br.read(&values)
+		br.read(&linedef)
+		br.read(&seg.Front)
+		seg.Start, seg.End = types.Vertex{X: values[0], Y: values[1]}, types.Vertex{X: values[2], Y: values[3]}
+		seg.Offset, seg.Linedef = values[4], int(linedef)
+		if seg.Linedef >= len(world.Linedefs) && br.err == nil {
+			br.err = fmt.Errorf("seg %d: linedef %d out of range", i, linedef)
+		}
+		out.Segs = append(out.Segs, seg)
+	}
+
+	br.read(&count)
+	for i := 0; i < int(count) && br.err == nil; i++ {
+		var values [2]uint32
+		br.read(&values)
+		if int(values[0])+int(values[1]) > len(out.Segs) && br.err == nil {
+			br.err = fmt.Errorf("subsector %d: segs out of range", i)
+		}
+		out.Subsectors = append(out.Subsectors, types.Subsector{FirstSeg: int(values[0]), NumSegs: int(values[1])})
+	}
+
+	br.read(&count)
+	for i := 0; i < int(count) && br.err == nil; i++ {
+		var values [4]float64
+		var children [2]int32
+		br.read(&values)
+		br.read(&children)
+		out.Nodes = append(out.Nodes, types.BSPNode{
+			X: values[0], Y: values[1], DX: values[2], DY: values[3],
+			Front: types.BSPChild(children[0]), Back: types.BSPChild(children[1]),
+		})
+	}
+	var root int32
+	br.read(&root)
+	out.Root = types.BSPChild(root)
+	if errors.Is(br.err, io.EOF) {
+		br.err = io.ErrUnexpectedEOF
+	}
+	if br.err != nil {
+		return nil, br.err
+	}
+	// Children always come after their parent, which also rules out cycles.
+	for i, node := range out.Nodes {
+		for _, child := range []types.BSPChild{node.Front, node.Back} {
+			if !out.ValidChild(child) || (!child.IsSubsector() && child.Index() <= i) {
+				return nil, fmt.Errorf("node %d: child %d out of range", i, child)
+			}
+		}
+	}
+	if !out.ValidChild(out.Root) {
+		return nil, fmt.Errorf("root %d out of range", out.Root)
+	}
+	return out, nil
+}
+
+func SaveBSP(path string, tree *types.BSPTree) error {
+	var file, err = os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = WriteBSP(file, tree); err != nil {
+		file.Close()
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return file.Close()
+}
+
+func LoadBSP(path string, world *types.WorldSector) (*types.BSPTree, error) {
+	var file, err = os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	tree, err := ReadBSP(file, world)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return tree, nil
+}
@@ -0,0 +1,95 @@
+package impl
+
+import (
+	"github.com/averseabfun/flux/interfaces"
+	"github.com/averseabfun/flux/types"
+)
+
+// Colormap maps every palette index to the palette entry closest to it at a
+// number of light levels. Level 0 is full brightness and the last level is black.
+// Shading never picks an entry brighter than the one it started from.
+type Colormap struct {
+	Levels [][256]types.PaletteIndex
+}
+
+func NewColormap(palette types.Palette, levels int) *Colormap {
+	if levels < 1 {
+		levels = 1
+	}
+	var out = &Colormap{Levels: make([][256]types.PaletteIndex, levels)}
+	for level := range out.Levels {
+		var brightness = 1.0
+		if levels > 1 {
+			brightness = float64(levels-1-level) / float64(levels-1)
+		}
+		for i, color := range palette {
+			if !color.IsValid() {
+				out.Levels[level][i] = types.PaletteIndex(i)
+				continue
+			}
+			var target = types.ColorLerp(types.Color{}, color, brightness)
+			out.Levels[level][i] = nearestPaletteIndex(palette, target, colorBrightness(color))
+		}
+	}
+	return out
+}
+
+func NewColormapFromRenderer(rr interfaces.ReadBackRenderer, levels int) (*Colormap, error) {
+	var palette, err = RendererPalette(rr)
+	if err != nil {
+		return nil, err
+	}
+	return NewColormap(palette, levels), nil
+}
+
+func colorBrightness(color types.Color) int {
+	return int(color.R) + int(color.G) + int(color.B)
+}
+
+// nearestPaletteIndex finds the valid entry closest to target among those
+// no brighter than maxBrightness.
+func nearestPaletteIndex(palette types.Palette, target types.Color, maxBrightness int) types.PaletteIndex {
+	var best types.PaletteIndex
+	var bestDistance = -1
+	for i, color := range palette {
+		if !color.IsValid() || colorBrightness(color) > maxBrightness {
+			continue
+		}
+		var dr, dg, db = int(color.R) - int(target.R), int(color.G) - int(target.G), int(color.B) - int(target.B)
+		var distance = 3*dr*dr + 4*dg*dg + 2*db*db
+		if bestDistance < 0 || distance < bestDistance {
+			best = types.PaletteIndex(i)
+			bestDistance = distance
+		}
+	}
+	return best
+}
+
+func (cm *Colormap) NumLevels() int {
+	return len(cm.Levels)
+}
+
+// Shade looks index up at level, clamping level to the available levels.
+func (cm *Colormap) Shade(index types.PaletteIndex, level int) types.PaletteIndex {
+	return cm.Levels[min(max(level, 0), len(cm.Levels)-1)][index]
+}
+
+// LightLevel picks a level from a light value, where 0 is fully lit and 255
+// is darkest, plus distance, reaching the last level at lightDistance.
+func (cm *Colormap) LightLevel(darkness uint8, distance float64, lightDistance float64) int {
+	var level = int(darkness) * len(cm.Levels) / 256
+	if lightDistance > 0 {
+		level += int(distance * float64(len(cm.Levels)) / lightDistance)
+	}
+	return min(max(level, 0), len(cm.Levels)-1)
+}
+
+type ShadedSampler struct {
+	Sampler  interfaces.Sampler
+	Colormap *Colormap
+	Level    int
+}
+
+func (ss *ShadedSampler) GetAtPoint(point types.SamplerPoint) types.PaletteIndex {
+	return ss.Colormap.Shade(ss.Sampler.GetAtPoint(point), ss.Level)
+}
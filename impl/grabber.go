@@ -2,32 +2,52 @@ package impl
 
 import (
 	"fmt"
+	"strings"
 
-	"github.com/go-gl/glfw/v3.3/glfw"
+	"github.com/averseabfun/flux/interfaces"
 )
 
 type DebugGrabber struct {
 	ValueToChange *bool
-	WhichAction   glfw.Action
-	Key           glfw.Key
-	Mods          glfw.ModifierKey
-	MouseButton   glfw.MouseButton
-	MouseAction   glfw.Action
-	MouseMods     glfw.ModifierKey
+	WhichAction   interfaces.Action
+	Key           interfaces.Key
+	Mods          interfaces.ModifierKey
+	MouseButton   interfaces.MouseButton
+	MouseAction   interfaces.Action
+	MouseMods     interfaces.ModifierKey
+
+	keyDown bool
+}
+
+// grabKeyAction reports whether a grabber bound to a key combination should
+// take this event. A release is only taken when the press was, so that a key
+// released while the modifiers happen to match still reaches whoever saw it
+// pressed.
+func grabKeyAction(down *bool, action interfaces.Action) bool {
+	switch action {
+	case interfaces.Press:
+		*down = true
+	case interfaces.Release:
+		if !*down {
+			return false
+		}
+		*down = false
+	}
+	return true
 }
 
-func (dg *DebugGrabber) GrabKey(key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) bool {
-	if key != dg.Key || mods != dg.Mods {
+func (dg *DebugGrabber) GrabKey(key interfaces.Key, scancode int, action interfaces.Action, mods interfaces.ModifierKey) bool {
+	if key != dg.Key || mods != dg.Mods || !grabKeyAction(&dg.keyDown, action) {
 		return false
 	}
-	fmt.Printf("Got key %s\"%s\" on action %s\n", GetModifierNames(mods), glfw.GetKeyName(key, scancode), GetActionName(action))
+	fmt.Printf("Got key %s\"%s\" on action %s\n", GetModifierNames(mods), GetKeyName(key), GetActionName(action))
 	if action == dg.WhichAction {
 		*dg.ValueToChange = !*dg.ValueToChange
 	}
 	return true
 }
 
-func (dg *DebugGrabber) GrabMouse(button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey, posX float64, posY float64) bool {
+func (dg *DebugGrabber) GrabMouse(button interfaces.MouseButton, action interfaces.Action, mods interfaces.ModifierKey, posX float64, posY float64) bool {
 	if button != dg.MouseButton || mods != dg.MouseMods {
 		return false
 	}
@@ -38,38 +58,72 @@ func (dg *DebugGrabber) GrabMouse(button glfw.MouseButton, action glfw.Action, m
 	return true
 }
 
-func GetModifierNames(mods glfw.ModifierKey) string {
+func GetModifierNames(mods interfaces.ModifierKey) string {
 	var out = ""
-	if mods&glfw.ModShift > 0 {
+	if mods&interfaces.ModShift > 0 {
 		out += "Shift+"
 	}
-	if mods&glfw.ModControl > 0 {
+	if mods&interfaces.ModControl > 0 {
 		out += "Control+"
 	}
-	if mods&glfw.ModAlt > 0 {
+	if mods&interfaces.ModAlt > 0 {
 		out += "Alt+"
 	}
-	if mods&glfw.ModSuper > 0 {
+	if mods&interfaces.ModSuper > 0 {
 		out += "Super+"
 	}
-	if mods&glfw.ModCapsLock > 0 {
+	if mods&interfaces.ModCapsLock > 0 {
 		out += "Caps Lock+"
 	}
-	if mods&glfw.ModNumLock > 0 {
+	if mods&interfaces.ModNumLock > 0 {
 		out += "Num Lock+"
 	}
 	return out
 }
 
-func GetActionName(action glfw.Action) string {
+// GetKeyName returns the printable character for key, or its numeric code for
+// keys without one.
+func GetKeyName(key interfaces.Key) string {
+	if key > interfaces.KeySpace && key <= interfaces.KeyGraveAccent {
+		return strings.ToLower(string(rune(key)))
+	}
+	return fmt.Sprintf("key %d", key)
+}
+
+func GetActionName(action interfaces.Action) string {
 	switch action {
-	case glfw.Press:
+	case interfaces.Press:
 		return "pressed"
-	case glfw.Release:
+	case interfaces.Release:
 		return "released"
-	case glfw.Repeat:
+	case interfaces.Repeat:
 		return "repeated"
 	default:
 		return "unknown"
 	}
 }
+
+type RecorderGrabber struct {
+	Recorder *FrameRecorder
+	Key      interfaces.Key
+	Mods     interfaces.ModifierKey
+
+	keyDown bool
+}
+
+func (rg *RecorderGrabber) GrabKey(key interfaces.Key, scancode int, action interfaces.Action, mods interfaces.ModifierKey) bool {
+	if key != rg.Key || mods != rg.Mods || !grabKeyAction(&rg.keyDown, action) {
+		return false
+	}
+	if action != interfaces.Press {
+		return true
+	}
+	if err := rg.Recorder.Toggle(); err != nil {
+		fmt.Printf("Recording failed: %s\n", err)
+	} else if rg.Recorder.IsRecording() {
+		fmt.Println("Started recording")
+	} else {
+		fmt.Printf("Stopped recording to %s\n", rg.Recorder.Path)
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package impl
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/averseabfun/flux/interfaces"
+)
+
+type GrabberStack struct {
+	grabbers      []interfaces.KeyGrabber
+	mouseGrabbers []interfaces.MouseGrabber
+}
+
+func (gs *GrabberStack) DispatchKey(key interfaces.Key, scancode int, action interfaces.Action, mods interfaces.ModifierKey) {
+	for _, grabber := range gs.grabbers {
+		if grabber.GrabKey(key, scancode, action, mods) {
+			break
+		}
+	}
+}
+
+func (gs *GrabberStack) DispatchMouse(button interfaces.MouseButton, action interfaces.Action, mods interfaces.ModifierKey, posX float64, posY float64) {
+	for _, grabber := range gs.mouseGrabbers {
+		if grabber.GrabMouse(button, action, mods, posX, posY) {
+			break
+		}
+	}
+}
+
+func (gs *GrabberStack) PushGrabber(grabber interfaces.KeyGrabber) {
+	gs.grabbers = append(gs.grabbers, grabber)
+}
+
+func (gs *GrabberStack) PopGrabber() (interfaces.KeyGrabber, error) {
+	if len(gs.grabbers) == 0 {
+		return nil, errors.New("empty stack")
+	}
+	var out = gs.grabbers[len(gs.grabbers)-1]
+	gs.grabbers = slices.Delete(gs.grabbers, len(gs.grabbers)-1, len(gs.grabbers))
+	return out, nil
+}
+
+func (gs *GrabberStack) PushGrabberAt(grabber interfaces.KeyGrabber, index uint32) {
+	gs.grabbers = slices.Insert(gs.grabbers, int(index), grabber)
+}
+
+func (gs *GrabberStack) PopGrabberAt(index uint32) (interfaces.KeyGrabber, error) {
+	if int(index) >= len(gs.grabbers) {
+		return nil, errors.New("too small stack")
+	}
+	var out = gs.grabbers[index]
+	gs.grabbers = slices.Delete(gs.grabbers, int(index), int(index)+1)
+	return out, nil
+}
+
+func (gs *GrabberStack) PushMouseGrabber(grabber interfaces.MouseGrabber) {
+	gs.mouseGrabbers = append(gs.mouseGrabbers, grabber)
+}
+
+func (gs *GrabberStack) PopMouseGrabber() (interfaces.MouseGrabber, error) {
+	if len(gs.mouseGrabbers) == 0 {
+		return nil, errors.New("empty stack")
+	}
+	var out = gs.mouseGrabbers[len(gs.mouseGrabbers)-1]
+	gs.mouseGrabbers = slices.Delete(gs.mouseGrabbers, len(gs.mouseGrabbers)-1, len(gs.mouseGrabbers))
+	return out, nil
+}
+
+func (gs *GrabberStack) PushMouseGrabberAt(grabber interfaces.MouseGrabber, index uint32) {
+	gs.mouseGrabbers = slices.Insert(gs.mouseGrabbers, int(index), grabber)
+}
+
+func (gs *GrabberStack) PopMouseGrabberAt(index uint32) (interfaces.MouseGrabber, error) {
+	if int(index) >= len(gs.mouseGrabbers) {
+		return nil, errors.New("too small stack")
+	}
+	var out = gs.mouseGrabbers[index]
+	gs.mouseGrabbers = slices.Delete(gs.mouseGrabbers, int(index), int(index)+1)
+	return out, nil
+}
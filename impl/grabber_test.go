@@ -0,0 +1,36 @@
+package impl
+
+import (
+	"testing"
+
+	"github.com/averseabfun/flux/interfaces"
+)
+
+func TestDebugGrabberPassesUnseenRelease(t *testing.T) {
+	var debug = false
+	var player = &PlayerController{}
+	var rr = &Headless{}
+	rr.PushGrabber(&DebugGrabber{ValueToChange: &debug, WhichAction: interfaces.Press, Key: interfaces.KeyD, Mods: interfaces.ModControl})
+	rr.PushGrabber(player)
+
+	rr.InjectKey(interfaces.KeyD, 0, interfaces.Press, 0)
+	if !player.IsHeld(PlayerStrafeRight) {
+		t.Fatal("D press did not reach the player")
+	}
+	rr.InjectKey(interfaces.KeyD, 0, interfaces.Release, interfaces.ModControl)
+	if player.IsHeld(PlayerStrafeRight) {
+		t.Error("D released while Ctrl was held left the player strafing")
+	}
+	if debug {
+		t.Error("debug toggled without a Ctrl+D press")
+	}
+
+	rr.InjectKey(interfaces.KeyD, 0, interfaces.Press, interfaces.ModControl)
+	rr.InjectKey(interfaces.KeyD, 0, interfaces.Release, interfaces.ModControl)
+	if !debug {
+		t.Error("Ctrl+D did not toggle debug")
+	}
+	if player.IsHeld(PlayerStrafeRight) {
+		t.Error("Ctrl+D reached the player")
+	}
+}
@@ -0,0 +1,129 @@
+package impl
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/averseabfun/flux/interfaces"
+	"github.com/averseabfun/flux/types"
+)
+
+// Headless is an in-memory RawRenderer, KeyProvider and MouseProvider that
+// needs no window or GL context, for tests and servers.
+type Headless struct {
+	palette     types.Palette
+	pixels      []types.PaletteIndex
+	width       uint32
+	height      uint32
+	frames      uint64
+	shouldClose bool
+	// FrameLimit makes ShouldQuit report true after this many ticks; 0 means no limit.
+	FrameLimit uint64
+	GrabberStack
+}
+
+func (rr *Headless) InitRenderer(windowName string, width uint32, height uint32) error {
+	if width == 0 || height == 0 {
+		return errors.New("got zero width or height")
+	}
+	rr.palette = types.NewInvalidPalette()
+	rr.pixels = make([]types.PaletteIndex, width*height)
+	rr.width = width
+	rr.height = height
+	rr.frames = 0
+	rr.shouldClose = false
+	return nil
+}
+
+func (rr *Headless) GetSize() types.Point {
+	return types.Point{X: rr.width, Y: rr.height}
+}
+
+func (rr *Headless) TickRenderer() {
+	rr.frames++
+}
+
+func (rr *Headless) GetFrameCount() uint64 {
+	return rr.frames
+}
+
+func (rr *Headless) ShouldQuit() bool {
+	return rr.shouldClose || (rr.FrameLimit > 0 && rr.frames >= rr.FrameLimit)
+}
+
+func (rr *Headless) Quit() {
+	rr.shouldClose = true
+}
+
+func (rr *Headless) DeinitRenderer() error {
+	rr.pixels = nil
+	rr.shouldClose = true
+	return nil
+}
+
+func (rr *Headless) DrawBackPixel(x uint32, y uint32, paletteIndex types.PaletteIndex) error {
+	if x >= rr.width {
+		return errors.New("got x over the width of the window")
+	}
+	if y >= rr.height {
+		return errors.New("got y over the height of the window")
+	}
+	rr.pixels[y*rr.width+x] = paletteIndex
+	return nil
+}
+
+func (rr *Headless) FillBack(paletteIndex types.PaletteIndex) error {
+	for i := range rr.pixels {
+		rr.pixels[i] = paletteIndex
+	}
+	return nil
+}
+
+func (rr *Headless) SetPaletteColor(paletteIndex types.PaletteIndex, color types.Color) error {
+	if !color.IsValid() {
+		return types.ErrInvalidColor
+	}
+	rr.palette[paletteIndex] = color
+	return nil
+}
+
+func (rr *Headless) GetBackPixel(x uint32, y uint32) (types.PaletteIndex, error) {
+	if x >= rr.width {
+		return 0, errors.New("got x over the width of the window")
+	}
+	if y >= rr.height {
+		return 0, errors.New("got y over the height of the window")
+	}
+	return rr.pixels[y*rr.width+x], nil
+}
+
+func (rr *Headless) GetPaletteColor(paletteIndex types.PaletteIndex) (types.Color, error) {
+	var color = rr.palette[paletteIndex]
+	if !color.IsValid() {
+		return types.InvalidColor, fmt.Errorf("%w: index %d", types.ErrUnsetColor, paletteIndex)
+	}
+	return color, nil
+}
+
+// GetRGBArray resolves the back buffer through the palette into 8-bit RGB
+// triples, row by row from the top-left corner.
+func (rr *Headless) GetRGBArray() ([]uint8, error) {
+	var out = make([]uint8, 0, len(rr.pixels)*3)
+	for _, idx := range rr.pixels {
+		var color = rr.palette[idx]
+		if !color.IsValid() {
+			return nil, errors.New("attempted to use invalid palette index(palette color is invalid)")
+		}
+		var r, g, b = color.RGB8()
+		out = append(out, r, g, b)
+	}
+	return out, nil
+}
+
+func (rr *Headless) InjectKey(key interfaces.Key, scancode int, action interfaces.Action, mods interfaces.ModifierKey) {
+	rr.DispatchKey(key, scancode, action, mods)
+}
+
+func (rr *Headless) InjectMouse(button interfaces.MouseButton, action interfaces.Action, mods interfaces.ModifierKey, posX float64, posY float64) {
+	rr.DispatchMouse(button, action, mods, posX, posY)
+}
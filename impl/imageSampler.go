@@ -0,0 +1,44 @@
+package impl
+
+import (
+	"math"
+
+	"github.com/averseabfun/flux/types"
+)
+
+// ImageSampler samples an indexed image, stretching it over the 0 to 1 range
+// of both axes, with 1 itself on the last row or column. Points outside that
+// range wrap around.
+type ImageSampler struct {
+	image *IndexedImage
+}
+
+func NewImageSampler(image *IndexedImage) *ImageSampler {
+	return &ImageSampler{image: image}
+}
+
+func (is *ImageSampler) GetImage() *IndexedImage {
+	return is.image
+}
+
+func (is *ImageSampler) SetImage(image *IndexedImage) {
+	is.image = image
+}
+
+func (is *ImageSampler) GetAtPoint(point types.SamplerPoint) types.PaletteIndex {
+	if is.image == nil || is.image.Width == 0 || is.image.Height == 0 {
+		return 0
+	}
+	return is.image.At(texel(point.X, is.image.Width), texel(point.Y, is.image.Height))
+}
+
+// texel maps a sampler coordinate to a pixel along an axis of size pixels.
+func texel(coord float64, size int) int {
+	if math.IsNaN(coord) || math.IsInf(coord, 0) {
+		return 0
+	}
+	if coord == 1 {
+		return size - 1
+	}
+	return min(int(math.Floor((coord-math.Floor(coord))*float64(size))), size-1)
+}
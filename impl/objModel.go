@@ -0,0 +1,341 @@
+package impl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/averseabfun/flux/interfaces"
+	"github.com/averseabfun/flux/types"
+)
+
+// OBJ files are right-handed with -Z forward, so the loader flips Z to match
+// flux's +Z forward. Seen from the front, faces stay counterclockwise.
+
+// OBJMaterial is the part of an MTL material flux can use: its diffuse color
+// matched to the palette, and its diffuse texture if it has one.
+type OBJMaterial struct {
+	Name    string
+	Color   types.PaletteIndex
+	Texture *IndexedImage
+}
+
+func (om *OBJMaterial) Sampler() interfaces.Sampler {
+	if om.Texture != nil {
+		return NewImageSampler(om.Texture)
+	}
+	var out = &FlatSampler{}
+	out.SetColor(om.Color)
+	return out
+}
+
+type OBJFace struct {
+	Poly     *types.Poly3D
+	Material *OBJMaterial
+}
+
+// OBJObject is one "o" or "g" group of a model.
+type OBJObject struct {
+	Name  string
+	Faces []OBJFace
+}
+
+type OBJModel struct {
+	Objects   []*OBJObject
+	Materials map[string]*OBJMaterial
+	// Warnings lists what was skipped while loading, such as a missing MTL
+	// library or an unknown material; the faces involved use the default
+	// material instead.
+	Warnings []error
+}
+
+// objDefaultDiffuse is the diffuse color the MTL format gives materials,
+// and faces with no material, when none is set.
+const objDefaultDiffuse = 0.8
+
+type objParser struct {
+	name     string
+	dir      string
+	palette  types.Palette
+	matcher  *paletteMatcher
+	model    *OBJModel
+	vertices []types.Point3D
+	uvs      []types.SamplerPoint
+	object   *OBJObject
+	// objectName names the object the next face starts, once "o" or "g"
+	// has closed the previous one.
+	objectName      string
+	material        *OBJMaterial
+	defaultMaterial *OBJMaterial
+	lineNumber      int
+}
+
+// LoadOBJ reads an OBJ file and the MTL libraries it names, which are looked
+// up next to it. Material colors are matched to palette.
+func LoadOBJ(path string, palette types.Palette) (*OBJModel, error) {
+	var file, err = os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadOBJ(file, path, filepath.Dir(path), palette)
+}
+
+// ReadOBJ reads an OBJ model from r; name is used in errors and dir is where
+// MTL libraries and textures are looked up.
+func ReadOBJ(r io.Reader, name string, dir string, palette types.Palette) (*OBJModel, error) {
+	var matcher, err = newPaletteMatcher(palette)
+	if err != nil {
+		return nil, err
+	}
+	var parser = &objParser{
+		name:    name,
+		dir:     dir,
+		palette: palette,
+		matcher: matcher,
+		model:   &OBJModel{Materials: make(map[string]*OBJMaterial)},
+	}
+	var scanner = bufio.NewScanner(r)
+	for scanner.Scan() {
+		parser.lineNumber++
+		var fields = strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if err := parser.parseLine(fields); err != nil {
+			if _, ok := err.(*MapError); ok {
+				return nil, err
+			}
+			return nil, &MapError{Path: name, Line: parser.lineNumber, Err: err}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return parser.model, nil
+}
+
+func parseFloats(fields []string, count int) ([]float64, error) {
+	if len(fields) < count {
+		return nil, fmt.Errorf("expected %d numbers, got %d", count, len(fields))
+	}
+	var out = make([]float64, count)
+	for i := range out {
+		var value, err = strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad number %q", fields[i])
+		}
+		out[i] = value
+	}
+	return out, nil
+}
+
+func (op *objParser) parseLine(fields []string) error {
+	switch fields[0] {
+	case "v":
+		var values, err = parseFloats(fields[1:], 3)
+		if err != nil {
+			return err
+		}
+		op.vertices = append(op.vertices, types.Point3D{X: values[0], Y: values[1], Z: -values[2]})
+	case "vt":
+		var values, err = parseFloats(fields[1:], 1)
+		if err != nil {
+			return err
+		}
+		var uv = types.SamplerPoint{X: values[0], Y: 1}
+		if len(fields) > 2 {
+			var v, err = parseFloats(fields[2:], 1)
+			if err != nil {
+				return err
+			}
+			uv.Y = 1 - v[0]
+		}
+		op.uvs = append(op.uvs, uv)
+	case "o", "g":
+		// A "g" before any faces names a group of the object just opened
+		// rather than a new object.
+		if op.object != nil || fields[0] == "o" || op.objectName == "" {
+			op.objectName = strings.Join(fields[1:], " ")
+		}
+		op.object = nil
+	case "usemtl":
+		if len(fields) < 2 {
+			return fmt.Errorf("usemtl needs a material name")
+		}
+		var material, ok = op.model.Materials[fields[1]]
+		if !ok {
+			op.warn(fmt.Errorf("unknown material %q", fields[1]))
+			material = nil
+		}
+		op.material = material
+	case "mtllib":
+		for _, library := range fields[1:] {
+			var err = op.loadMTL(filepath.Join(op.dir, library))
+			if errors.Is(err, fs.ErrNotExist) {
+				op.warn(err)
+			} else if err != nil {
+				return err
+			}
+		}
+	case "f":
+		return op.parseFace(fields[1:])
+	}
+	return nil
+}
+
+// objIndex resolves a 1-based, or negative relative, OBJ index.
+func objIndex(field string, count int) (int, error) {
+	var index, err = strconv.Atoi(field)
+	if err != nil {
+		return 0, fmt.Errorf("bad index %q", field)
+	}
+	if index < 0 {
+		index += count + 1
+	}
+	if index < 1 || index > count {
+		return 0, fmt.Errorf("index %s out of range (have %d)", field, count)
+	}
+	return index - 1, nil
+}
+
+func (op *objParser) parseFace(fields []string) error {
+	if len(fields) < 3 {
+		return fmt.Errorf("face needs at least 3 vertices, got %d", len(fields))
+	}
+	var points = make([]types.Point3D, len(fields))
+	var uvs = make([]types.SamplerPoint, len(fields))
+	var hasUVs = true
+	for i, field := range fields {
+		var parts = strings.Split(field, "/")
+		var vertex, err = objIndex(parts[0], len(op.vertices))
+		if err != nil {
+			return err
+		}
+		points[i] = op.vertices[vertex]
+		if len(parts) < 2 || parts[1] == "" {
+			hasUVs = false
+			continue
+		}
+		uv, err := objIndex(parts[1], len(op.uvs))
+		if err != nil {
+			return err
+		}
+		uvs[i] = op.uvs[uv]
+	}
+	if op.object == nil {
+		var name = op.objectName
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(op.name), filepath.Ext(op.name))
+		}
+		op.object = &OBJObject{Name: name}
+		op.model.Objects = append(op.model.Objects, op.object)
+	}
+	var material = op.material
+	if material == nil {
+		if op.defaultMaterial == nil {
+			op.defaultMaterial = &OBJMaterial{Color: op.nearest(objDefaultDiffuse, objDefaultDiffuse, objDefaultDiffuse)}
+		}
+		material = op.defaultMaterial
+	}
+	// Fan out from the first corner; this assumes n-gons are convex, as OBJ
+	// exporters write them.
+	for i := 2; i < len(points); i++ {
+		var corners = []int{0, i - 1, i}
+		var poly = &types.Poly3D{Points: make([]types.Point3D, 0, 3)}
+		if hasUVs {
+			poly.SamplerPoints = make(map[types.Point3D]types.SamplerPoint, 3)
+		}
+		for _, corner := range corners {
+			poly.Points = append(poly.Points, points[corner])
+			if hasUVs {
+				poly.SamplerPoints[points[corner]] = uvs[corner]
+			}
+		}
+		op.object.Faces = append(op.object.Faces, OBJFace{Poly: poly, Material: material})
+	}
+	return nil
+}
+
+func (op *objParser) warn(err error) {
+	op.model.Warnings = append(op.model.Warnings, &MapError{Path: op.name, Line: op.lineNumber, Err: err})
+}
+
+func (op *objParser) nearest(r float64, g float64, b float64) types.PaletteIndex {
+	return op.matcher.nearest(clampChannel(r*255), clampChannel(g*255), clampChannel(b*255))
+}
+
+func (op *objParser) loadMTL(path string) error {
+	var file, err = os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var scanner = bufio.NewScanner(file)
+	var lineNumber = 0
+	var material *OBJMaterial
+	for scanner.Scan() {
+		lineNumber++
+		var fields = strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		switch fields[0] {
+		case "newmtl":
+			if len(fields) < 2 {
+				return mapErrorf(path, lineNumber, "newmtl needs a material name")
+			}
+			material = &OBJMaterial{Name: fields[1], Color: op.nearest(objDefaultDiffuse, objDefaultDiffuse, objDefaultDiffuse)}
+			op.model.Materials[material.Name] = material
+		case "Kd", "map_Kd":
+			if material == nil {
+				return mapErrorf(path, lineNumber, "%s before newmtl", fields[0])
+			}
+			if fields[0] == "map_Kd" {
+				if len(fields) < 2 {
+					return mapErrorf(path, lineNumber, "map_Kd needs a file name")
+				}
+				var texture, err = LoadIndexedImage(filepath.Join(filepath.Dir(path), fields[len(fields)-1]), QuantizeOptions{Palette: &op.palette})
+				if errors.Is(err, fs.ErrNotExist) {
+					op.model.Warnings = append(op.model.Warnings, &MapError{Path: path, Line: lineNumber, Err: err})
+					continue
+				}
+				if err != nil {
+					return &MapError{Path: path, Line: lineNumber, Err: err}
+				}
+				material.Texture = texture
+				continue
+			}
+			var values, err = parseFloats(fields[1:], 3)
+			if err != nil {
+				return &MapError{Path: path, Line: lineNumber, Err: err}
+			}
+			material.Color = op.nearest(values[0], values[1], values[2])
+		}
+	}
+	return scanner.Err()
+}
+
+// AddToWorld adds the object to world under parent, or at the root if
+// parent is types.NoObject, as a group with one child per face. It returns
+// the group's ID.
+func (oo *OBJObject) AddToWorld(world *types.World3D, parent types.ObjectID) (types.ObjectID, error) {
+	var group, err = world.AddChild(parent, &types.Object3D{Name: oo.Name})
+	if err != nil {
+		return types.NoObject, err
+	}
+	for _, face := range oo.Faces {
+		var object = &types.Object3D{Name: oo.Name, Shape: face.Poly, Sampler: face.Material.Sampler()}
+		if _, err = world.AddChild(group, object); err != nil {
+			return group, err
+		}
+	}
+	return group, nil
+}
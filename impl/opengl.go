@@ -1,9 +1,10 @@
+//go:build cgo
+
 package impl
 
 import (
 	"errors"
-	"math"
-	"slices"
+	"fmt"
 
 	"github.com/averseabfun/flux/interfaces"
 	"github.com/averseabfun/flux/types"
@@ -12,16 +13,15 @@ import (
 )
 
 type OpenGL struct {
-	window        *glfw.Window
-	palette       map[types.PaletteIndex]types.Color
-	pixels        [][]types.PaletteIndex
-	texture       uint32
-	width         uint32
-	height        uint32
-	shouldClose   bool
-	grabbers      []interfaces.KeyGrabber
-	mouseGrabbers []interfaces.MouseGrabber
-	focused       bool
+	window      *glfw.Window
+	palette     types.Palette
+	pixels      [][]types.PaletteIndex
+	texture     uint32
+	width       uint32
+	height      uint32
+	shouldClose bool
+	focused     bool
+	GrabberStack
 }
 
 func (rr *OpenGL) InitRenderer(windowName string, width uint32, height uint32) error {
@@ -53,10 +53,7 @@ func (rr *OpenGL) InitRenderer(windowName string, width uint32, height uint32) e
 
 	gl.Viewport(0, 0, int32(width)*4, int32(height)*4)
 
-	rr.palette = make(map[types.PaletteIndex]types.Color)
-	for i := types.PaletteIndex(0); i < math.MaxUint8; i++ {
-		rr.palette[i] = types.InvalidColor
-	}
+	rr.palette = types.NewInvalidPalette()
 
 	rr.pixels = make([][]types.PaletteIndex, 0)
 	for x := uint32(0); x < width; x++ {
@@ -140,7 +137,8 @@ func (rr *OpenGL) GetRGBArray() []uint8 {
 				rr.DeinitRenderer()
 				panic("attempted to use invalid palette index(palette color is invalid)")
 			}
-			out = append(out, uint8(rr.palette[idx].R)*4, uint8(rr.palette[idx].G)*4, uint8(rr.palette[idx].B)*4)
+			var r, g, b = rr.palette[idx].RGB8()
+			out = append(out, r, g, b)
 		}
 	}
 	return out
@@ -206,19 +204,36 @@ func (rr *OpenGL) FillBack(paletteIndex types.PaletteIndex) error {
 }
 
 func (rr *OpenGL) SetPaletteColor(paletteIndex types.PaletteIndex, color types.Color) error {
+	if !color.IsValid() {
+		return types.ErrInvalidColor
+	}
 	rr.palette[paletteIndex] = color
 	return nil
 }
 
+func (rr *OpenGL) GetBackPixel(x uint32, y uint32) (types.PaletteIndex, error) {
+	if x >= rr.width {
+		return 0, errors.New("got x over the width of the window")
+	}
+	if y >= rr.height {
+		return 0, errors.New("got y over the height of the window")
+	}
+	return rr.pixels[rr.width-1-x][rr.height-1-y], nil
+}
+
+func (rr *OpenGL) GetPaletteColor(paletteIndex types.PaletteIndex) (types.Color, error) {
+	var color = rr.palette[paletteIndex]
+	if !color.IsValid() {
+		return types.InvalidColor, fmt.Errorf("%w: index %d", types.ErrUnsetColor, paletteIndex)
+	}
+	return color, nil
+}
+
 func (rr *OpenGL) key_callback(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
 	if !rr.focused {
 		return
 	}
-	for _, grabber := range rr.grabbers {
-		if grabber.GrabKey(key, scancode, action, mods) {
-			break
-		}
-	}
+	rr.DispatchKey(interfaces.Key(key), scancode, interfaces.Action(action), interfaces.ModifierKey(mods))
 }
 
 func (rr *OpenGL) mouse_button_callback(w *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
@@ -228,65 +243,9 @@ func (rr *OpenGL) mouse_button_callback(w *glfw.Window, button glfw.MouseButton,
 	var posX, posY = rr.window.GetCursorPos()
 	posX /= 4
 	posY /= 4
-	for _, grabber := range rr.mouseGrabbers {
-		if grabber.GrabMouse(button, action, mods, posX, posY) {
-			break
-		}
-	}
+	rr.DispatchMouse(interfaces.MouseButton(button), interfaces.Action(action), interfaces.ModifierKey(mods), posX, posY)
 }
 
 func (rr *OpenGL) focus_callback(w *glfw.Window, focused bool) {
 	rr.focused = focused
 }
-
-func (rr *OpenGL) PushGrabber(grabber interfaces.KeyGrabber) {
-	rr.grabbers = append(rr.grabbers, grabber)
-}
-
-func (rr *OpenGL) PopGrabber() (interfaces.KeyGrabber, error) {
-	if len(rr.grabbers) == 0 {
-		return nil, errors.New("empty stack")
-	}
-	var out = rr.grabbers[len(rr.grabbers)-1]
-	rr.grabbers = slices.Delete(rr.grabbers, len(rr.grabbers)-1, len(rr.grabbers))
-	return out, nil
-}
-
-func (rr *OpenGL) PushGrabberAt(grabber interfaces.KeyGrabber, index uint32) {
-	rr.grabbers = slices.Insert(rr.grabbers, int(index), grabber)
-}
-
-func (rr *OpenGL) PopGrabberAt(index uint32) (interfaces.KeyGrabber, error) {
-	if int(index) >= len(rr.grabbers) {
-		return nil, errors.New("too small stack")
-	}
-	var out = rr.grabbers[index]
-	rr.grabbers = slices.Delete(rr.grabbers, int(index), int(index)+1)
-	return out, nil
-}
-
-func (rr *OpenGL) PushMouseGrabber(grabber interfaces.MouseGrabber) {
-	rr.mouseGrabbers = append(rr.mouseGrabbers, grabber)
-}
-
-func (rr *OpenGL) PopMouseGrabber() (interfaces.MouseGrabber, error) {
-	if len(rr.mouseGrabbers) == 0 {
-		return nil, errors.New("empty stack")
-	}
-	var out = rr.mouseGrabbers[len(rr.grabbers)-1]
-	rr.mouseGrabbers = slices.Delete(rr.mouseGrabbers, len(rr.mouseGrabbers)-1, len(rr.mouseGrabbers))
-	return out, nil
-}
-
-func (rr *OpenGL) PushMouseGrabberAt(grabber interfaces.MouseGrabber, index uint32) {
-	rr.mouseGrabbers = slices.Insert(rr.mouseGrabbers, int(index), grabber)
-}
-
-func (rr *OpenGL) PopMouseGrabberAt(index uint32) (interfaces.MouseGrabber, error) {
-	if int(index) >= len(rr.mouseGrabbers) {
-		return nil, errors.New("too small stack")
-	}
-	var out = rr.mouseGrabbers[index]
-	rr.mouseGrabbers = slices.Delete(rr.mouseGrabbers, int(index), int(index)+1)
-	return out, nil
-}
@@ -0,0 +1,365 @@
+package impl
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/averseabfun/flux/interfaces"
+	"github.com/averseabfun/flux/types"
+)
+
+type PaletteFormat int
+
+const (
+	// PaletteJASC is the Paint Shop Pro text format: "JASC-PAL", "0100", the
+	// entry count, then one "r g b" line per entry with 8-bit channels.
+	PaletteJASC PaletteFormat = iota
+	// PaletteGIMP is GIMP's .gpl text format with 8-bit channels.
+	PaletteGIMP
+	// PaletteACT is Adobe's raw format: 256 8-bit RGB triples, optionally
+	// followed by a big-endian entry count and transparent index.
+	PaletteACT
+	// PaletteVGA is the raw DOS format: 256 RGB triples with 6-bit channels,
+	// as written to the VGA DAC.
+	PaletteVGA
+)
+
+const rawPaletteSize = 256 * 3
+
+var ErrUnknownPaletteFormat = errors.New("unknown palette format")
+
+// PaletteRangeError lists the palette entries whose channels were out of range.
+type PaletteRangeError struct {
+	Entries []int
+}
+
+func (pre *PaletteRangeError) Error() string {
+	var entries = make([]string, len(pre.Entries))
+	for i, entry := range pre.Entries {
+		entries[i] = strconv.Itoa(entry)
+	}
+	return "palette entries out of range: " + strings.Join(entries, ", ")
+}
+
+// paletteBuilder fills a palette entry by entry, remembering entries whose
+// channels did not fit. Entries that are never set stay black.
+type paletteBuilder struct {
+	palette  types.Palette
+	outRange []int
+}
+
+func (pb *paletteBuilder) set(index int, r int, g int, b int, limit int) {
+	if r < 0 || g < 0 || b < 0 || r > limit || g > limit || b > limit {
+		pb.outRange = append(pb.outRange, index)
+		return
+	}
+	if limit == int(types.MAX_UINT6) {
+		pb.palette[index], _ = types.FromRGBUint8(uint8(r), uint8(g), uint8(b))
+		return
+	}
+	pb.palette[index] = types.FromRGB8(uint8(r), uint8(g), uint8(b))
+}
+
+func (pb *paletteBuilder) result() (types.Palette, error) {
+	if len(pb.outRange) > 0 {
+		return pb.palette, &PaletteRangeError{Entries: pb.outRange}
+	}
+	return pb.palette, nil
+}
+
+func PaletteFormatForPath(path string) (PaletteFormat, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".pal":
+		return PaletteJASC, nil
+	case ".gpl":
+		return PaletteGIMP, nil
+	case ".act":
+		return PaletteACT, nil
+	case ".vga", ".dat":
+		return PaletteVGA, nil
+	}
+	return 0, fmt.Errorf("%w: %s", ErrUnknownPaletteFormat, path)
+}
+
+// DetectPaletteFormat sniffs the format from a file's contents. Raw files
+// whose channels all fit in 6 bits are taken to be VGA palettes.
+func DetectPaletteFormat(data []byte) (PaletteFormat, error) {
+	if format, ok := paletteFormatForHeader(data); ok {
+		return format, nil
+	}
+	switch {
+	case len(data) == rawPaletteSize || len(data) == rawPaletteSize+4:
+		for _, value := range data[:rawPaletteSize] {
+			if value > uint8(types.MAX_UINT6) {
+				return PaletteACT, nil
+			}
+		}
+		return PaletteVGA, nil
+	}
+	return 0, ErrUnknownPaletteFormat
+}
+
+func paletteFormatForHeader(data []byte) (PaletteFormat, bool) {
+	switch {
+	case bytes.HasPrefix(data, []byte("JASC-PAL")):
+		return PaletteJASC, true
+	case bytes.HasPrefix(data, []byte("GIMP Palette")):
+		return PaletteGIMP, true
+	}
+	return 0, false
+}
+
+// LoadPalette reads a palette file in any supported format. The text formats
+// are recognized by their headers; a headerless file is read as ACT or VGA
+// by its extension, or by DetectPaletteFormat for .pal and unknown
+// extensions, since .pal is used for both text and raw palettes. If some
+// entries are out of range the rest of the palette is still returned, along
+// with a *PaletteRangeError.
+func LoadPalette(path string) (types.Palette, error) {
+	var data, err = os.ReadFile(path)
+	if err != nil {
+		return types.Palette{}, err
+	}
+	format, ok := paletteFormatForHeader(data)
+	if !ok {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".act":
+			format = PaletteACT
+		case ".vga", ".dat":
+			format = PaletteVGA
+		default:
+			format, err = DetectPaletteFormat(data)
+		}
+	}
+	if err != nil {
+		return types.Palette{}, fmt.Errorf("%s: %w", path, err)
+	}
+	palette, err := ReadPalette(bytes.NewReader(data), format)
+	if err != nil {
+		return palette, fmt.Errorf("%s: %w", path, err)
+	}
+	return palette, nil
+}
+
+func ReadPalette(r io.Reader, format PaletteFormat) (types.Palette, error) {
+	switch format {
+	case PaletteJASC:
+		return readJASCPalette(r)
+	case PaletteGIMP:
+		return readGIMPPalette(r)
+	case PaletteACT:
+		return readRawPalette(r, 255)
+	case PaletteVGA:
+		return readRawPalette(r, int(types.MAX_UINT6))
+	}
+	return types.Palette{}, ErrUnknownPaletteFormat
+}
+
+func readRawPalette(r io.Reader, limit int) (types.Palette, error) {
+	var data = make([]byte, rawPaletteSize)
+	if _, err := io.ReadFull(r, data); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return types.Palette{}, err
+	}
+	var builder paletteBuilder
+	for i := range builder.palette {
+		builder.set(i, int(data[i*3]), int(data[i*3+1]), int(data[i*3+2]), limit)
+	}
+	return builder.result()
+}
+
+// parsePaletteLine reads the first three fields of a text palette line as channels.
+func parsePaletteLine(line string) (int, int, int, error) {
+	var fields = strings.Fields(line)
+	if len(fields) < 3 {
+		return 0, 0, 0, fmt.Errorf("expected r g b, got %q", line)
+	}
+	var values [3]int
+	for i := range values {
+		var value, err = strconv.Atoi(fields[i])
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("bad channel %q", fields[i])
+		}
+		values[i] = value
+	}
+	return values[0], values[1], values[2], nil
+}
+
+func readJASCPalette(r io.Reader) (types.Palette, error) {
+	var scanner = bufio.NewScanner(r)
+	var lineNumber = 0
+	var next = func() (string, bool) {
+		for scanner.Scan() {
+			lineNumber++
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				return line, true
+			}
+		}
+		return "", false
+	}
+	if line, _ := next(); line != "JASC-PAL" {
+		return types.Palette{}, fmt.Errorf("%w: missing JASC-PAL header", ErrUnknownPaletteFormat)
+	}
+	if line, _ := next(); line != "0100" {
+		return types.Palette{}, fmt.Errorf("%w: JASC version %q", ErrUnknownPaletteFormat, line)
+	}
+	var countLine, _ = next()
+	var count, err = strconv.Atoi(countLine)
+	if err != nil || count < 0 || count > len(types.Palette{}) {
+		return types.Palette{}, fmt.Errorf("line %d: bad entry count %q", lineNumber, countLine)
+	}
+	var builder paletteBuilder
+	for i := 0; i < count; i++ {
+		var line, ok = next()
+		if !ok {
+			return types.Palette{}, fmt.Errorf("expected %d entries, got %d", count, i)
+		}
+		r, g, b, err := parsePaletteLine(line)
+		if err != nil {
+			return types.Palette{}, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		builder.set(i, r, g, b, 255)
+	}
+	if err = scanner.Err(); err != nil {
+		return types.Palette{}, err
+	}
+	return builder.result()
+}
+
+func readGIMPPalette(r io.Reader) (types.Palette, error) {
+	var scanner = bufio.NewScanner(r)
+	var lineNumber = 0
+	var index = 0
+	var builder paletteBuilder
+	for scanner.Scan() {
+		lineNumber++
+		var line = strings.TrimSpace(scanner.Text())
+		if lineNumber == 1 {
+			if line != "GIMP Palette" {
+				return types.Palette{}, fmt.Errorf("%w: missing GIMP Palette header", ErrUnknownPaletteFormat)
+			}
+			continue
+		}
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "Name:") || strings.HasPrefix(line, "Columns:") {
+			continue
+		}
+		if index >= len(builder.palette) {
+			return types.Palette{}, fmt.Errorf("line %d: more than %d entries", lineNumber, len(builder.palette))
+		}
+		var r, g, b, err = parsePaletteLine(line)
+		if err != nil {
+			return types.Palette{}, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		builder.set(index, r, g, b, 255)
+		index++
+	}
+	if err := scanner.Err(); err != nil {
+		return types.Palette{}, err
+	}
+	if lineNumber == 0 {
+		return types.Palette{}, fmt.Errorf("%w: empty file", ErrUnknownPaletteFormat)
+	}
+	return builder.result()
+}
+
+// invalidEntries lists the entries of palette that are not valid colors.
+func invalidEntries(palette types.Palette) error {
+	var out []int
+	for i, color := range palette {
+		if !color.IsValid() {
+			out = append(out, i)
+		}
+	}
+	if len(out) > 0 {
+		return &PaletteRangeError{Entries: out}
+	}
+	return nil
+}
+
+func WritePalette(w io.Writer, palette types.Palette, format PaletteFormat) error {
+	if err := invalidEntries(palette); err != nil {
+		return err
+	}
+	var bw = bufio.NewWriter(w)
+	switch format {
+	case PaletteJASC:
+		fmt.Fprintf(bw, "JASC-PAL\r\n0100\r\n%d\r\n", len(palette))
+		for _, color := range palette {
+			var r, g, b = color.RGB8()
+			fmt.Fprintf(bw, "%d %d %d\r\n", r, g, b)
+		}
+	case PaletteGIMP:
+		fmt.Fprintf(bw, "GIMP Palette\nName: flux\nColumns: 16\n#\n")
+		for i, color := range palette {
+			var r, g, b = color.RGB8()
+			fmt.Fprintf(bw, "%3d %3d %3d\tIndex %d\n", r, g, b, i)
+		}
+	case PaletteACT:
+		for _, color := range palette {
+			var r, g, b = color.RGB8()
+			bw.Write([]byte{r, g, b})
+		}
+	case PaletteVGA:
+		for _, color := range palette {
+			bw.Write([]byte{uint8(color.R), uint8(color.G), uint8(color.B)})
+		}
+	default:
+		return ErrUnknownPaletteFormat
+	}
+	return bw.Flush()
+}
+
+// SavePalette writes palette in the format given by the path's extension.
+func SavePalette(path string, palette types.Palette) error {
+	var format, err = PaletteFormatForPath(path)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = WritePalette(file, palette, format); err != nil {
+		file.Close()
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return file.Close()
+}
+
+// ApplyPalette sets all 256 palette entries of rr. Every valid entry is set
+// even if some are not, and the invalid ones are reported together.
+func ApplyPalette(rr interfaces.RawRenderer, palette types.Palette) error {
+	for i, color := range palette {
+		if color.IsValid() {
+			if err := rr.SetPaletteColor(types.PaletteIndex(i), color); err != nil {
+				return err
+			}
+		}
+	}
+	return invalidEntries(palette)
+}
+
+// RendererPalette reads all 256 palette entries of rr; entries nothing was
+// set to come back as types.InvalidColor.
+func RendererPalette(rr interfaces.ReadBackRenderer) (types.Palette, error) {
+	var out types.Palette
+	for i := range out {
+		var color, err = rr.GetPaletteColor(types.PaletteIndex(i))
+		if errors.Is(err, types.ErrUnsetColor) {
+			color = types.InvalidColor
+		} else if err != nil {
+			return out, err
+		}
+		out[i] = color
+	}
+	return out, nil
+}
@@ -0,0 +1,176 @@
+package impl
+
+import (
+	"math"
+	"time"
+
+	"github.com/averseabfun/flux/interfaces"
+	"github.com/averseabfun/flux/types"
+)
+
+// PaletteCycle rotates the entries from Start to End inclusive by Speed
+// entries per second; a negative Speed rotates the other way.
+type PaletteCycle struct {
+	Start types.PaletteIndex
+	End   types.PaletteIndex
+	Speed float64
+
+	offset float64
+}
+
+// PaletteEffects owns a shadow copy of the palette and layers effects over
+// it: a fade between two palettes replaces Base while it runs, cycles rotate
+// ranges of entries, and a flash tints everything on top. Call Update and
+// then Apply once per frame.
+type PaletteEffects struct {
+	Base   types.Palette
+	Cycles []*PaletteCycle
+
+	fadeFrom     types.Palette
+	fadeTo       types.Palette
+	fadeDuration time.Duration
+	fadeElapsed  time.Duration
+	fading       bool
+
+	flashColor    types.Color
+	flashStrength float64
+	flashDuration time.Duration
+	flashElapsed  time.Duration
+	flashing      bool
+
+	applied    types.Palette
+	hasApplied bool
+}
+
+func NewPaletteEffects(base types.Palette) *PaletteEffects {
+	return &PaletteEffects{Base: base}
+}
+
+func (pe *PaletteEffects) AddCycle(start types.PaletteIndex, end types.PaletteIndex, speed float64) *PaletteCycle {
+	var cycle = &PaletteCycle{Start: min(start, end), End: max(start, end), Speed: speed}
+	pe.Cycles = append(pe.Cycles, cycle)
+	return cycle
+}
+
+// FadeTo fades from the current palette to target over duration, leaving
+// target as the new Base once it is done.
+func (pe *PaletteEffects) FadeTo(target types.Palette, duration time.Duration) {
+	pe.FadeBetween(pe.faded(), target, duration)
+}
+
+func (pe *PaletteEffects) FadeBetween(from types.Palette, to types.Palette, duration time.Duration) {
+	pe.fadeFrom, pe.fadeTo = from, to
+	pe.fadeDuration, pe.fadeElapsed = duration, 0
+	pe.fading = true
+	if duration <= 0 {
+		pe.finishFade()
+	}
+}
+
+// FadeToColor fades every entry to color, such as black for a fade-out.
+func (pe *PaletteEffects) FadeToColor(color types.Color, duration time.Duration) {
+	var target types.Palette
+	for i := range target {
+		target[i] = color
+	}
+	pe.FadeTo(target, duration)
+}
+
+func (pe *PaletteEffects) IsFading() bool {
+	return pe.fading
+}
+
+func (pe *PaletteEffects) finishFade() {
+	pe.Base = pe.fadeTo
+	pe.fading = false
+}
+
+// Flash tints the palette toward color, starting at strength (0 to 1) and
+// fading out linearly over duration. A new flash replaces the current one.
+func (pe *PaletteEffects) Flash(color types.Color, strength float64, duration time.Duration) {
+	pe.flashColor = color
+	pe.flashStrength = min(max(strength, 0), 1)
+	pe.flashDuration, pe.flashElapsed = duration, 0
+	pe.flashing = duration > 0
+}
+
+func (pe *PaletteEffects) Update(elapsed time.Duration) {
+	for _, cycle := range pe.Cycles {
+		var length = float64(cycle.End) - float64(cycle.Start) + 1
+		cycle.offset = math.Mod(cycle.offset+cycle.Speed*elapsed.Seconds(), length)
+		if cycle.offset < 0 {
+			cycle.offset += length
+		}
+	}
+	if pe.fading {
+		pe.fadeElapsed += elapsed
+		if pe.fadeElapsed >= pe.fadeDuration {
+			pe.finishFade()
+		}
+	}
+	if pe.flashing {
+		pe.flashElapsed += elapsed
+		pe.flashing = pe.flashElapsed < pe.flashDuration
+	}
+}
+
+// lerpColor is types.ColorLerp, except that invalid entries are left alone.
+func lerpColor(from types.Color, to types.Color, t float64) types.Color {
+	if !from.IsValid() || !to.IsValid() {
+		return from
+	}
+	return types.ColorLerp(from, to, t)
+}
+
+func (pe *PaletteEffects) faded() types.Palette {
+	if !pe.fading {
+		return pe.Base
+	}
+	var out types.Palette
+	var t = pe.fadeElapsed.Seconds() / pe.fadeDuration.Seconds()
+	for i := range out {
+		out[i] = lerpColor(pe.fadeFrom[i], pe.fadeTo[i], t)
+	}
+	return out
+}
+
+// Palette is the palette with every effect applied.
+func (pe *PaletteEffects) Palette() types.Palette {
+	var source = pe.faded()
+	var out = source
+	for _, cycle := range pe.Cycles {
+		var length = int(cycle.End) - int(cycle.Start) + 1
+		var shift = int(cycle.offset)
+		for i := 0; i < length; i++ {
+			out[int(cycle.Start)+(i+shift)%length] = source[int(cycle.Start)+i]
+		}
+	}
+	if pe.flashing {
+		var t = pe.flashStrength * (1 - pe.flashElapsed.Seconds()/pe.flashDuration.Seconds())
+		for i := range out {
+			out[i] = lerpColor(out[i], pe.flashColor, t)
+		}
+	}
+	return out
+}
+
+// Apply pushes the entries that changed since the last Apply to rr.
+func (pe *PaletteEffects) Apply(rr interfaces.RawRenderer) error {
+	var palette = pe.Palette()
+	for i, color := range palette {
+		if !color.IsValid() || (pe.hasApplied && pe.applied[i] == color) {
+			continue
+		}
+		if err := rr.SetPaletteColor(types.PaletteIndex(i), color); err != nil {
+			return err
+		}
+	}
+	pe.applied, pe.hasApplied = palette, true
+	return nil
+}
+
+// Invalidate makes the next Apply push every entry, for when something else
+// has changed the renderer's palette.
+func (pe *PaletteEffects) Invalidate() {
+	pe.hasApplied = false
+}
@@ -0,0 +1,50 @@
+package impl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/averseabfun/flux/types"
+)
+
+func testPalette() types.Palette {
+	var out types.Palette
+	for i := range out {
+		out[i], _ = types.FromRGBUint8(uint8(i%64), uint8(i/4), uint8(63-i%64))
+	}
+	return out
+}
+
+func TestLoadPaletteSniffsBeforeExtension(t *testing.T) {
+	var palette = testPalette()
+	for _, tc := range []struct {
+		name   string
+		format PaletteFormat
+	}{
+		{"jasc.pal", PaletteJASC},
+		{"jasc.act", PaletteJASC},
+		{"gimp.pal", PaletteGIMP},
+		{"gimp.dat", PaletteGIMP},
+		{"raw-vga.pal", PaletteVGA},
+		{"raw-act.pal", PaletteACT},
+		{"raw.act", PaletteACT},
+		{"raw.vga", PaletteVGA},
+	} {
+		var buf bytes.Buffer
+		if err := WritePalette(&buf, palette, tc.format); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		var path = filepath.Join(t.TempDir(), tc.name)
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		var got, err = LoadPalette(path)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+		} else if got != palette {
+			t.Errorf("%s: loaded palette differs from the one written", tc.name)
+		}
+	}
+}
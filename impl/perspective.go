@@ -0,0 +1,158 @@
+package impl
+
+import (
+	"math"
+
+	"github.com/averseabfun/flux/interfaces"
+	"github.com/averseabfun/flux/types"
+)
+
+var PerspectiveDefaultFOV types.Degree = 90
+var PerspectiveNearClip float64 = 0.1
+
+// PerspectiveRenderer projects 3D shapes onto the screen and fills them with
+// its PolyRenderer. The camera looks down +Z with +X to the right and +Y up
+// before its rotation is applied; the rotation turns it by Y (yaw, positive
+// turns right), then X (pitch, positive looks up), then Z (roll).
+type PerspectiveRenderer struct {
+	rr           interfaces.RawRenderer
+	polyRenderer interfaces.PolyRenderer
+	FOV          types.Degree
+}
+
+// clipVertex is a polygon corner during clipping, with its sampler point.
+type clipVertex struct {
+	x  float64
+	y  float64
+	z  float64
+	uv types.SamplerPoint
+}
+
+func lerpClipVertex(a clipVertex, b clipVertex, t float64) clipVertex {
+	return clipVertex{
+		x:  types.Lerp(a.x, b.x, t),
+		y:  types.Lerp(a.y, b.y, t),
+		z:  types.Lerp(a.z, b.z, t),
+		uv: types.SamplerPoint{X: types.Lerp(a.uv.X, b.uv.X, t), Y: types.Lerp(a.uv.Y, b.uv.Y, t)},
+	}
+}
+
+// clipPolygon keeps the part of a closed polygon where distance is not
+// negative, using Sutherland-Hodgman.
+func clipPolygon(vertices []clipVertex, distance func(clipVertex) float64) []clipVertex {
+	var out = make([]clipVertex, 0, len(vertices)+2)
+	for i, current := range vertices {
+		var previous = vertices[(i+len(vertices)-1)%len(vertices)]
+		var dCurrent, dPrevious = distance(current), distance(previous)
+		if (dCurrent >= 0) != (dPrevious >= 0) {
+			out = append(out, lerpClipVertex(previous, current, dPrevious/(dPrevious-dCurrent)))
+		}
+		if dCurrent >= 0 {
+			out = append(out, current)
+		}
+	}
+	return out
+}
+
+func (pr *PerspectiveRenderer) Parent() interfaces.RawRenderer {
+	return pr.rr
+}
+
+func (pr *PerspectiveRenderer) SetParent(rr interfaces.RawRenderer) {
+	pr.rr = rr
+}
+
+func (pr *PerspectiveRenderer) CanUseCurrentRawRenderer() bool {
+	return true
+}
+
+func (pr *PerspectiveRenderer) GetPolyRenderer() interfaces.PolyRenderer {
+	if pr.polyRenderer == nil {
+		pr.polyRenderer = &PolyRenderer{}
+	}
+	pr.polyRenderer.SetParent(pr.Parent())
+	return pr.polyRenderer
+}
+
+func (pr *PerspectiveRenderer) SetPolyRenderer(polyRenderer interfaces.PolyRenderer) {
+	pr.polyRenderer = polyRenderer
+}
+
+// toCamera moves a world point into camera space.
+func toCamera(point types.Point3D, cameraPos types.Point3D, cameraRotation types.Rotation3D) (float64, float64, float64) {
+	var out = cameraRotation.Matrix().Transpose().MulDirection(point.Vec3().Sub(cameraPos.Vec3()))
+	return out.X, out.Y, out.Z
+}
+
+// Project returns the screen position of a world point, and false if it is
+// behind the near plane.
+func (pr *PerspectiveRenderer) Project(point types.Point3D, cameraPos types.Point3D, cameraRotation types.Rotation3D) (types.SamplerPoint, bool) {
+	var x, y, z = toCamera(point, cameraPos, cameraRotation)
+	if z < PerspectiveNearClip {
+		return types.SamplerPoint{}, false
+	}
+	var size = pr.rr.GetSize()
+	var focal = pr.focal(size)
+	return types.SamplerPoint{X: float64(size.X)/2 + x*focal/z, Y: float64(size.Y)/2 - y*focal/z}, true
+}
+
+func (pr *PerspectiveRenderer) focal(size types.Point) float64 {
+	var fov = pr.FOV
+	if fov == 0 {
+		fov = PerspectiveDefaultFOV
+	}
+	return float64(size.X) / 2 / math.Tan(float64((fov / 2).ToRadians()))
+}
+
+func (pr *PerspectiveRenderer) RenderShape(shape interfaces.Shape3D, cameraPos types.Point3D, cameraRotation types.Rotation3D, sampler interfaces.Sampler) {
+	var points = shape.GetPoints()
+	if len(points) > 1 && points[len(points)-1] == points[0] {
+		points = points[:len(points)-1]
+	}
+	if len(points) == 0 {
+		return
+	}
+	var samplerPoints = shape.GetSamplerPoints()
+
+	var vertices = make([]clipVertex, len(points))
+	for i, point := range points {
+		var x, y, z = toCamera(point, cameraPos, cameraRotation)
+		vertices[i] = clipVertex{x: x, y: y, z: z, uv: samplerPoints[point]}
+	}
+	vertices = clipPolygon(vertices, func(v clipVertex) float64 { return v.z - PerspectiveNearClip })
+	if len(vertices) == 0 {
+		return
+	}
+
+	var size = pr.rr.GetSize()
+	var focal = pr.focal(size)
+	for i, v := range vertices {
+		vertices[i].x = float64(size.X)/2 + v.x*focal/v.z
+		vertices[i].y = float64(size.Y)/2 - v.y*focal/v.z
+	}
+	var right, bottom = float64(size.X) - 1, float64(size.Y) - 1
+	vertices = clipPolygon(vertices, func(v clipVertex) float64 { return v.x })
+	vertices = clipPolygon(vertices, func(v clipVertex) float64 { return right - v.x })
+	vertices = clipPolygon(vertices, func(v clipVertex) float64 { return v.y })
+	vertices = clipPolygon(vertices, func(v clipVertex) float64 { return bottom - v.y })
+	if len(vertices) == 0 {
+		return
+	}
+
+	var poly = &types.Poly{SamplerPoints: make(map[types.Point]types.SamplerPoint, len(vertices))}
+	for _, v := range vertices {
+		var point = types.Point{X: uint32(math.Round(v.x)), Y: uint32(math.Round(v.y))}
+		if len(poly.Points) > 0 && poly.Points[len(poly.Points)-1] == point {
+			continue
+		}
+		poly.Points = append(poly.Points, point)
+		poly.SamplerPoints[point] = v.uv
+	}
+	if samplerPoints == nil {
+		poly.SamplerPoints = types.MakePolySamplerPoints(poly.Points)
+	}
+	if poly.Points[len(poly.Points)-1] != poly.Points[0] || len(poly.Points) == 1 {
+		poly.Points = append(poly.Points, poly.Points[0])
+	}
+	pr.GetPolyRenderer().DrawPoly(poly, sampler)
+}
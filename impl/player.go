@@ -0,0 +1,138 @@
+package impl
+
+import (
+	"math"
+	"time"
+
+	"github.com/averseabfun/flux/interfaces"
+	"github.com/averseabfun/flux/types"
+)
+
+var PlayerDefaultWalkSpeed float64 = 8
+var PlayerDefaultStrafeSpeed float64 = 6
+var PlayerDefaultTurnSpeed float64 = 120
+var PlayerDefaultRadius float64 = 0.25
+
+type PlayerAction uint8
+
+const (
+	PlayerForward = PlayerAction(iota)
+	PlayerBackward
+	PlayerStrafeLeft
+	PlayerStrafeRight
+	PlayerTurnLeft
+	PlayerTurnRight
+	playerActionCount
+)
+
+func DefaultPlayerBindings() map[interfaces.Key]PlayerAction {
+	return map[interfaces.Key]PlayerAction{
+		interfaces.KeyW:     PlayerForward,
+		interfaces.KeyUp:    PlayerForward,
+		interfaces.KeyS:     PlayerBackward,
+		interfaces.KeyDown:  PlayerBackward,
+		interfaces.KeyA:     PlayerStrafeLeft,
+		interfaces.KeyD:     PlayerStrafeRight,
+		interfaces.KeyLeft:  PlayerTurnLeft,
+		interfaces.KeyRight: PlayerTurnRight,
+	}
+}
+
+// PlayerController is a KeyGrabber that tracks which movement keys are held
+// and moves a camera through a WorldWolf when Update is called each frame.
+// Speeds are in world units (or degrees) per second; zero values use the
+// PlayerDefault variables, and nil Bindings use DefaultPlayerBindings.
+type PlayerController struct {
+	Position    types.SamplerPoint
+	Heading     types.Degree
+	WalkSpeed   float64
+	StrafeSpeed float64
+	TurnSpeed   float64
+	Radius      float64
+	Bindings    map[interfaces.Key]PlayerAction
+
+	held [playerActionCount]bool
+}
+
+func (pc *PlayerController) GrabKey(key interfaces.Key, scancode int, action interfaces.Action, mods interfaces.ModifierKey) bool {
+	if pc.Bindings == nil {
+		pc.Bindings = DefaultPlayerBindings()
+	}
+	var playerAction, ok = pc.Bindings[key]
+	if !ok {
+		return false
+	}
+	switch action {
+	case interfaces.Press:
+		pc.held[playerAction] = true
+	case interfaces.Release:
+		pc.held[playerAction] = false
+	}
+	return true
+}
+
+func (pc *PlayerController) IsHeld(action PlayerAction) bool {
+	return pc.held[action]
+}
+
+func orDefault(value float64, fallback float64) float64 {
+	if value == 0 {
+		return fallback
+	}
+	return value
+}
+
+func heldAxis(negative bool, positive bool) float64 {
+	var out float64
+	if negative {
+		out--
+	}
+	if positive {
+		out++
+	}
+	return out
+}
+
+// Update turns and moves the player by the time elapsed since the last frame.
+func (pc *PlayerController) Update(world types.WorldWolf, elapsed time.Duration) {
+	var seconds = elapsed.Seconds()
+	var turn = heldAxis(pc.held[PlayerTurnLeft], pc.held[PlayerTurnRight])
+	pc.Heading = types.Degree(math.Mod(float64(pc.Heading)+turn*orDefault(pc.TurnSpeed, PlayerDefaultTurnSpeed)*seconds, 360))
+	if pc.Heading < 0 {
+		pc.Heading += 360
+	}
+
+	var rads = float64(pc.Heading.ToRadians())
+	var forwardX, forwardY = math.Cos(rads), math.Sin(rads)
+	var walk = heldAxis(pc.held[PlayerBackward], pc.held[PlayerForward]) * orDefault(pc.WalkSpeed, PlayerDefaultWalkSpeed) * seconds
+	var strafe = heldAxis(pc.held[PlayerStrafeLeft], pc.held[PlayerStrafeRight]) * orDefault(pc.StrafeSpeed, PlayerDefaultStrafeSpeed) * seconds
+	pc.Move(world, forwardX*walk-forwardY*strafe, forwardY*walk+forwardX*strafe)
+}
+
+// Move moves the player by (dx, dy), sliding along any wall it runs into.
+// Long moves are split into steps no longer than the player's radius so
+// walls cannot be skipped over.
+func (pc *PlayerController) Move(world types.WorldWolf, dx float64, dy float64) {
+	var radius = orDefault(pc.Radius, PlayerDefaultRadius)
+	var steps = max(int(math.Ceil(math.Hypot(dx, dy)/radius)), 1)
+	dx /= float64(steps)
+	dy /= float64(steps)
+	for i := 0; i < steps; i++ {
+		if !pc.collides(world, pc.Position.X+dx, pc.Position.Y, radius) {
+			pc.Position.X += dx
+		}
+		if !pc.collides(world, pc.Position.X, pc.Position.Y+dy, radius) {
+			pc.Position.Y += dy
+		}
+	}
+}
+
+func (pc *PlayerController) collides(world types.WorldWolf, x float64, y float64, radius float64) bool {
+	for _, object := range world.Objects {
+		if x+radius > float64(object.Start.X) && x-radius < float64(object.End.X)+1 &&
+			y+radius > float64(object.Start.Y) && y-radius < float64(object.End.Y)+1 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,311 @@
+package impl
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"os"
+	"slices"
+
+	"github.com/averseabfun/flux/interfaces"
+	"github.com/averseabfun/flux/types"
+)
+
+type DitherMode int
+
+const (
+	DitherNone DitherMode = iota
+	DitherFloydSteinberg
+	DitherBayer
+)
+
+// BayerSpread is how far, in 8-bit channel steps, ordered dithering may push
+// a pixel's color either way before matching it.
+var BayerSpread float64 = 32
+
+var ErrEmptyPalette = errors.New("palette has no valid entries")
+
+var bayer8 = [8][8]float64{
+	{0, 32, 8, 40, 2, 34, 10, 42},
+	{48, 16, 56, 24, 50, 18, 58, 26},
+	{12, 44, 4, 36, 14, 46, 6, 38},
+	{60, 28, 52, 20, 62, 30, 54, 22},
+	{3, 35, 11, 43, 1, 33, 9, 41},
+	{51, 19, 59, 27, 49, 17, 57, 25},
+	{15, 47, 7, 39, 13, 45, 5, 37},
+	{63, 31, 55, 23, 61, 29, 53, 21},
+}
+
+// IndexedImage is an image whose pixels are palette indices, row by row from
+// the top-left corner.
+type IndexedImage struct {
+	Width   int
+	Height  int
+	Pixels  []types.PaletteIndex
+	Palette types.Palette
+}
+
+func (ii *IndexedImage) At(x int, y int) types.PaletteIndex {
+	return ii.Pixels[y*ii.Width+x]
+}
+
+type QuantizeOptions struct {
+	Dither DitherMode
+	// Palette is matched against; if nil, one is generated from the image
+	// with median cut, using at most Colors entries (256 if zero).
+	Palette *types.Palette
+	Colors  int
+}
+
+type labColor struct {
+	L float64
+	A float64
+	B float64
+}
+
+func linearize(channel float64) float64 {
+	channel /= 255
+	if channel <= 0.04045 {
+		return channel / 12.92
+	}
+	return math.Pow((channel+0.055)/1.055, 2.4)
+}
+
+func labF(t float64) float64 {
+	if t > 216.0/24389 {
+		return math.Cbrt(t)
+	}
+	return (24389.0/27*t + 16) / 116
+}
+
+// toLab converts 8-bit sRGB to CIE L*a*b* under D65.
+func toLab(r float64, g float64, b float64) labColor {
+	r, g, b = linearize(r), linearize(g), linearize(b)
+	var x = (0.4124564*r + 0.3575761*g + 0.1804375*b) / 0.95047
+	var y = 0.2126729*r + 0.7151522*g + 0.0721750*b
+	var z = (0.0193339*r + 0.1191920*g + 0.9503041*b) / 1.08883
+	var fx, fy, fz = labF(x), labF(y), labF(z)
+	return labColor{L: 116*fy - 16, A: 500 * (fx - fy), B: 200 * (fy - fz)}
+}
+
+// paletteMatcher finds the perceptually nearest palette entry to an 8-bit color.
+type paletteMatcher struct {
+	indices []types.PaletteIndex
+	labs    []labColor
+	cache   map[uint32]types.PaletteIndex
+}
+
+func newPaletteMatcher(palette types.Palette) (*paletteMatcher, error) {
+	var out = &paletteMatcher{cache: make(map[uint32]types.PaletteIndex)}
+	for i, color := range palette {
+		if !color.IsValid() {
+			continue
+		}
+		var r, g, b = color.RGB8()
+		out.indices = append(out.indices, types.PaletteIndex(i))
+		out.labs = append(out.labs, toLab(float64(r), float64(g), float64(b)))
+	}
+	if len(out.indices) == 0 {
+		return nil, ErrEmptyPalette
+	}
+	return out, nil
+}
+
+func (pm *paletteMatcher) nearest(r uint8, g uint8, b uint8) types.PaletteIndex {
+	var key = uint32(r)<<16 | uint32(g)<<8 | uint32(b)
+	if index, ok := pm.cache[key]; ok {
+		return index
+	}
+	var target = toLab(float64(r), float64(g), float64(b))
+	var best = 0
+	var bestDistance = math.Inf(1)
+	for i, lab := range pm.labs {
+		var dl, da, db = lab.L - target.L, lab.A - target.A, lab.B - target.B
+		if distance := dl*dl + da*da + db*db; distance < bestDistance {
+			best, bestDistance = i, distance
+		}
+	}
+	pm.cache[key] = pm.indices[best]
+	return pm.indices[best]
+}
+
+func clampChannel(value float64) uint8 {
+	return uint8(min(max(math.Round(value), 0), 255))
+}
+
+// imageRGB flattens img into 8-bit RGB triples, compositing over black.
+func imageRGB(img image.Image) ([]float64, int, int) {
+	var bounds = img.Bounds()
+	var width, height = bounds.Dx(), bounds.Dy()
+	var out = make([]float64, 0, width*height*3)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			var r, g, b, _ = img.At(x, y).RGBA()
+			out = append(out, float64(r>>8), float64(g>>8), float64(b>>8))
+		}
+	}
+	return out, width, height
+}
+
+func QuantizeImage(img image.Image, options QuantizeOptions) (*IndexedImage, error) {
+	var rgb, width, height = imageRGB(img)
+	var palette types.Palette
+	if options.Palette != nil {
+		palette = *options.Palette
+	} else {
+		palette = medianCut(rgb, options.Colors)
+	}
+	var matcher, err = newPaletteMatcher(palette)
+	if err != nil {
+		return nil, err
+	}
+
+	var out = &IndexedImage{Width: width, Height: height, Pixels: make([]types.PaletteIndex, width*height), Palette: palette}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var i = y*width + x
+			var r, g, b = rgb[i*3], rgb[i*3+1], rgb[i*3+2]
+			if options.Dither == DitherBayer {
+				var offset = (bayer8[y%8][x%8]/64 - 0.5 + 1.0/128) * BayerSpread
+				r, g, b = r+offset, g+offset, b+offset
+			}
+			var index = matcher.nearest(clampChannel(r), clampChannel(g), clampChannel(b))
+			out.Pixels[i] = index
+			if options.Dither != DitherFloydSteinberg {
+				continue
+			}
+			var pr, pg, pb = palette[index].RGB8()
+			var errR, errG, errB = r - float64(pr), g - float64(pg), b - float64(pb)
+			var spread = func(dx int, dy int, weight float64) {
+				var nx, ny = x + dx, y + dy
+				if nx < 0 || nx >= width || ny >= height {
+					return
+				}
+				var j = (ny*width + nx) * 3
+				rgb[j] += errR * weight
+				rgb[j+1] += errG * weight
+				rgb[j+2] += errB * weight
+			}
+			spread(1, 0, 7.0/16)
+			spread(-1, 1, 3.0/16)
+			spread(0, 1, 5.0/16)
+			spread(1, 1, 1.0/16)
+		}
+	}
+	return out, nil
+}
+
+// QuantizeForRenderer quantizes img onto rr's current palette, or onto a
+// generated one if rr has no valid palette entries yet.
+func QuantizeForRenderer(img image.Image, rr interfaces.ReadBackRenderer, dither DitherMode) (*IndexedImage, error) {
+	var palette, err = RendererPalette(rr)
+	if err != nil {
+		return nil, err
+	}
+	var hasValid = false
+	for _, color := range palette {
+		hasValid = hasValid || color.IsValid()
+	}
+	var options = QuantizeOptions{Dither: dither}
+	if hasValid {
+		options.Palette = &palette
+	}
+	return QuantizeImage(img, options)
+}
+
+// LoadIndexedImage decodes a PNG, GIF or JPEG file and quantizes it.
+func LoadIndexedImage(path string, options QuantizeOptions) (*IndexedImage, error) {
+	var file, err = os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return QuantizeImage(img, options)
+}
+
+type colorBox struct {
+	pixels [][3]float64
+}
+
+// widest returns the channel with the largest range in the box and that range.
+func (cb colorBox) widest() (int, float64) {
+	var best, bestRange = 0, -1.0
+	for channel := 0; channel < 3; channel++ {
+		var low, high = math.Inf(1), math.Inf(-1)
+		for _, pixel := range cb.pixels {
+			low, high = min(low, pixel[channel]), max(high, pixel[channel])
+		}
+		if high-low > bestRange {
+			best, bestRange = channel, high-low
+		}
+	}
+	return best, bestRange
+}
+
+// MedianCutPalette builds a palette of at most colors entries (256 if zero)
+// for img. Entries past the generated colors are types.InvalidColor, and an
+// empty image gets a single black entry.
+func MedianCutPalette(img image.Image, colors int) types.Palette {
+	var rgb, _, _ = imageRGB(img)
+	return medianCut(rgb, colors)
+}
+
+func medianCut(rgb []float64, colors int) types.Palette {
+	if colors <= 0 || colors > 256 {
+		colors = 256
+	}
+	var out = types.NewInvalidPalette()
+	if len(rgb) == 0 {
+		out[0] = types.Color{}
+		return out
+	}
+	var all = make([][3]float64, len(rgb)/3)
+	for i := range all {
+		all[i] = [3]float64{rgb[i*3], rgb[i*3+1], rgb[i*3+2]}
+	}
+	var boxes = []colorBox{{pixels: all}}
+	for len(boxes) < colors {
+		var split, splitChannel, splitRange = -1, 0, 0.0
+		for i, box := range boxes {
+			if len(box.pixels) < 2 {
+				continue
+			}
+			if channel, extent := box.widest(); extent > splitRange {
+				split, splitChannel, splitRange = i, channel, extent
+			}
+		}
+		if split < 0 {
+			break
+		}
+		var pixels = boxes[split].pixels
+		slices.SortStableFunc(pixels, func(a [3]float64, b [3]float64) int {
+			switch {
+			case a[splitChannel] < b[splitChannel]:
+				return -1
+			case a[splitChannel] > b[splitChannel]:
+				return 1
+			}
+			return 0
+		})
+		var median = len(pixels) / 2
+		boxes[split] = colorBox{pixels: pixels[:median]}
+		boxes = append(boxes, colorBox{pixels: pixels[median:]})
+	}
+	for i, box := range boxes {
+		var sum [3]float64
+		for _, pixel := range box.pixels {
+			sum[0], sum[1], sum[2] = sum[0]+pixel[0], sum[1]+pixel[1], sum[2]+pixel[2]
+		}
+		var count = float64(len(box.pixels))
+		out[i] = types.FromRGB8(clampChannel(sum[0]/count), clampChannel(sum[1]/count), clampChannel(sum[2]/count))
+	}
+	return out
+}
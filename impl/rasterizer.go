@@ -0,0 +1,155 @@
+package impl
+
+import (
+	"math"
+
+	"github.com/averseabfun/flux/interfaces"
+	"github.com/averseabfun/flux/types"
+)
+
+// TriangleRasterizer fills 3D shapes as triangle fans with a depth buffer,
+// interpolating depth and sampler points with perspective correction. It
+// uses the same camera as PerspectiveRenderer. Front faces wind
+// counterclockwise as seen from the camera; with CullBackfaces set the
+// others are skipped.
+type TriangleRasterizer struct {
+	rr            interfaces.RawRenderer
+	FOV           types.Degree
+	CullBackfaces bool
+
+	depth     []float64
+	depthSize types.Point
+}
+
+// screenVertex is a projected corner: screen position, 1/z and sampler
+// point divided by z.
+type screenVertex struct {
+	x      float64
+	y      float64
+	invZ   float64
+	uOverZ float64
+	vOverZ float64
+}
+
+func (tr *TriangleRasterizer) Parent() interfaces.RawRenderer {
+	return tr.rr
+}
+
+func (tr *TriangleRasterizer) SetParent(rr interfaces.RawRenderer) {
+	tr.rr = rr
+}
+
+func (tr *TriangleRasterizer) CanUseCurrentRawRenderer() bool {
+	return true
+}
+
+// ClearDepth empties the depth buffer, resizing it to the renderer if needed.
+func (tr *TriangleRasterizer) ClearDepth() {
+	var size = tr.rr.GetSize()
+	if size != tr.depthSize || tr.depth == nil {
+		tr.depth = make([]float64, int(size.X)*int(size.Y))
+		tr.depthSize = size
+	}
+	for i := range tr.depth {
+		tr.depth[i] = math.Inf(1)
+	}
+}
+
+// DepthAt is the camera-space depth stored for a pixel, or +Inf if nothing
+// has been drawn there since the last ClearDepth.
+func (tr *TriangleRasterizer) DepthAt(x uint32, y uint32) float64 {
+	if x >= tr.depthSize.X || y >= tr.depthSize.Y {
+		return math.Inf(1)
+	}
+	return tr.depth[int(y)*int(tr.depthSize.X)+int(x)]
+}
+
+func (tr *TriangleRasterizer) RenderShape(shape interfaces.Shape3D, cameraPos types.Point3D, cameraRotation types.Rotation3D, sampler interfaces.Sampler) {
+	if tr.depth == nil || tr.rr.GetSize() != tr.depthSize {
+		tr.ClearDepth()
+	}
+	var points = shape.GetPoints()
+	if len(points) > 1 && points[len(points)-1] == points[0] {
+		points = points[:len(points)-1]
+	}
+	if len(points) < 3 {
+		return
+	}
+	var samplerPoints = shape.GetSamplerPoints()
+	var vertices = make([]clipVertex, len(points))
+	for i, point := range points {
+		var x, y, z = toCamera(point, cameraPos, cameraRotation)
+		vertices[i] = clipVertex{x: x, y: y, z: z, uv: samplerPoints[point]}
+	}
+	vertices = clipPolygon(vertices, func(v clipVertex) float64 { return v.z - PerspectiveNearClip })
+	if len(vertices) < 3 {
+		return
+	}
+
+	var fov = tr.FOV
+	if fov == 0 {
+		fov = PerspectiveDefaultFOV
+	}
+	var size = tr.depthSize
+	var focal = float64(size.X) / 2 / math.Tan(float64((fov / 2).ToRadians()))
+	var projected = make([]screenVertex, len(vertices))
+	for i, v := range vertices {
+		projected[i] = screenVertex{
+			x:      float64(size.X)/2 + v.x*focal/v.z,
+			y:      float64(size.Y)/2 - v.y*focal/v.z,
+			invZ:   1 / v.z,
+			uOverZ: v.uv.X / v.z,
+			vOverZ: v.uv.Y / v.z,
+		}
+	}
+	for i := 2; i < len(projected); i++ {
+		tr.drawTriangle(projected[0], projected[i-1], projected[i], sampler)
+	}
+}
+
+func edge(a screenVertex, b screenVertex, x float64, y float64) float64 {
+	return (b.x-a.x)*(y-a.y) - (b.y-a.y)*(x-a.x)
+}
+
+func (tr *TriangleRasterizer) drawTriangle(a screenVertex, b screenVertex, c screenVertex, sampler interfaces.Sampler) {
+	var area = edge(a, b, c.x, c.y)
+	if area == 0 {
+		return
+	}
+	// Screen Y points down, so counterclockwise faces have a negative area here.
+	if area > 0 {
+		if tr.CullBackfaces {
+			return
+		}
+		b, c = c, b
+		area = -area
+	}
+
+	var minX = max(int(math.Floor(min(a.x, b.x, c.x))), 0)
+	var maxX = min(int(math.Ceil(max(a.x, b.x, c.x))), int(tr.depthSize.X)-1)
+	var minY = max(int(math.Floor(min(a.y, b.y, c.y))), 0)
+	var maxY = min(int(math.Ceil(max(a.y, b.y, c.y))), int(tr.depthSize.Y)-1)
+	for y := minY; y <= maxY; y++ {
+		var centerY = float64(y) + 0.5
+		for x := minX; x <= maxX; x++ {
+			var centerX = float64(x) + 0.5
+			var wA, wB, wC = edge(b, c, centerX, centerY), edge(c, a, centerX, centerY), edge(a, b, centerX, centerY)
+			if wA > 0 || wB > 0 || wC > 0 {
+				continue
+			}
+			wA, wB, wC = wA/area, wB/area, wC/area
+			var invZ = wA*a.invZ + wB*b.invZ + wC*c.invZ
+			var z = 1 / invZ
+			var index = y*int(tr.depthSize.X) + x
+			if z >= tr.depth[index] {
+				continue
+			}
+			tr.depth[index] = z
+			var point = types.SamplerPoint{
+				X: (wA*a.uOverZ + wB*b.uOverZ + wC*c.uOverZ) * z,
+				Y: (wA*a.vOverZ + wB*b.vOverZ + wC*c.vOverZ) * z,
+			}
+			tr.rr.DrawBackPixel(uint32(x), uint32(y), sampler.GetAtPoint(point))
+		}
+	}
+}
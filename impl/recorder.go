@@ -0,0 +1,135 @@
+package impl
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"os"
+	"time"
+
+	"github.com/averseabfun/flux/interfaces"
+)
+
+type RecordingFormat uint8
+
+const (
+	RecordGIF = RecordingFormat(iota)
+	RecordPNGSequence
+)
+
+// RecordGIFMaxBytes caps the pixel data a GIF recording holds in memory
+// until it is written. Reaching it stops and saves the recording; 0 means no
+// limit.
+var RecordGIFMaxBytes = 256 << 20
+
+var ErrRecordingLimit = errors.New("recording size limit reached")
+
+// FrameRecorder captures frames from a ReadBackRenderer while recording.
+// GIF recordings are written to Path when stopped; PNG sequences are written
+// as they are captured, to Path followed by a zero-padded frame number.
+type FrameRecorder struct {
+	Format RecordingFormat
+	Path   string
+	Scale  uint32
+
+	recording   bool
+	frames      []*image.Paletted
+	delays      []int
+	bytes       int
+	frameNumber int
+}
+
+func (fr *FrameRecorder) IsRecording() bool {
+	return fr.recording
+}
+
+func (fr *FrameRecorder) Start() {
+	fr.recording = true
+	fr.frames = nil
+	fr.delays = nil
+	fr.bytes = 0
+	fr.frameNumber = 0
+}
+
+func (fr *FrameRecorder) Stop() error {
+	if !fr.recording {
+		return errors.New("not recording")
+	}
+	fr.recording = false
+	if fr.Format != RecordGIF {
+		return nil
+	}
+	var frames, delays = fr.frames, fr.delays
+	fr.frames = nil
+	fr.delays = nil
+	fr.bytes = 0
+	if len(frames) == 0 {
+		return errors.New("no frames were recorded")
+	}
+	if len(delays) > 1 {
+		delays[len(delays)-1] = delays[len(delays)-2]
+	}
+	file, err := os.Create(fr.Path)
+	if err != nil {
+		return err
+	}
+	if err := gif.EncodeAll(file, &gif.GIF{Image: frames, Delay: delays}); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (fr *FrameRecorder) Toggle() error {
+	if fr.recording {
+		return fr.Stop()
+	}
+	fr.Start()
+	return nil
+}
+
+// CaptureFrame records the current frame of rr if recording. sincePrevious is
+// the time since the previous capture and becomes the previous frame's delay.
+// A GIF recording that would grow past RecordGIFMaxBytes is stopped and
+// saved without the frame, and ErrRecordingLimit is returned.
+func (fr *FrameRecorder) CaptureFrame(rr interfaces.ReadBackRenderer, sincePrevious time.Duration) error {
+	if !fr.recording {
+		return nil
+	}
+	var img, err = CaptureFrame(rr, fr.Scale)
+	if err != nil {
+		return err
+	}
+	switch fr.Format {
+	case RecordGIF:
+		if RecordGIFMaxBytes > 0 && fr.bytes+len(img.Pix) > RecordGIFMaxBytes {
+			if err := fr.Stop(); err != nil {
+				return err
+			}
+			return fmt.Errorf("%w; saved to %s", ErrRecordingLimit, fr.Path)
+		}
+		if len(fr.delays) > 0 {
+			fr.delays[len(fr.delays)-1] = max(int(sincePrevious.Round(10*time.Millisecond)/(10*time.Millisecond)), 2)
+		}
+		fr.frames = append(fr.frames, img)
+		fr.delays = append(fr.delays, 2)
+		fr.bytes += len(img.Pix)
+	case RecordPNGSequence:
+		file, err := os.Create(fmt.Sprintf("%s%05d.png", fr.Path, fr.frameNumber))
+		if err != nil {
+			return err
+		}
+		if err := WritePNG(file, img); err != nil {
+			file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown recording format %d", fr.Format)
+	}
+	fr.frameNumber++
+	return nil
+}
@@ -0,0 +1,18 @@
+package impl
+
+import (
+	"github.com/averseabfun/flux/interfaces"
+	"github.com/averseabfun/flux/types"
+	"github.com/averseabfun/flux/vecmath"
+)
+
+// RenderWorld3D draws every visible object in world that has a shape and a
+// sampler, placing each by its world transform.
+func RenderWorld3D(renderer interfaces.Shape3DRenderer, world *types.World3D, cameraPos types.Point3D, cameraRotation types.Rotation3D) {
+	world.Walk(func(object *types.Object3D, matrix vecmath.Mat4) {
+		if object.Shape == nil || object.Sampler == nil {
+			return
+		}
+		renderer.RenderShape(object.WorldShape(matrix), cameraPos, cameraRotation, object.Sampler)
+	})
+}
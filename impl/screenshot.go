@@ -0,0 +1,104 @@
+package impl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/averseabfun/flux/interfaces"
+	"github.com/averseabfun/flux/types"
+)
+
+var ErrUnknownScreenshotFormat = errors.New("unknown screenshot format")
+
+// CaptureFrame reads the back buffer and palette of rr into a paletted image,
+// scaling every pixel up to a scale*scale block. A scale of 0 is treated as 1.
+func CaptureFrame(rr interfaces.ReadBackRenderer, scale uint32) (*image.Paletted, error) {
+	if scale == 0 {
+		scale = 1
+	}
+	var size = rr.GetSize()
+	var colors, err = RendererPalette(rr)
+	if err != nil {
+		return nil, err
+	}
+	var palette = make(color.Palette, len(colors))
+	for i, clr := range colors {
+		palette[i] = clr
+	}
+	var out = image.NewPaletted(image.Rect(0, 0, int(size.X*scale), int(size.Y*scale)), palette)
+	for y := uint32(0); y < size.Y; y++ {
+		for x := uint32(0); x < size.X; x++ {
+			var idx, err = rr.GetBackPixel(x, y)
+			if err != nil {
+				return nil, err
+			}
+			if !palette[idx].(types.Color).IsValid() {
+				return nil, fmt.Errorf("pixel (%d, %d) uses palette index %d which has an invalid color", x, y, idx)
+			}
+			for sy := uint32(0); sy < scale; sy++ {
+				var row = out.PixOffset(int(x*scale), int(y*scale+sy))
+				for sx := uint32(0); sx < scale; sx++ {
+					out.Pix[row+int(sx)] = uint8(idx)
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+func WritePNG(w io.Writer, img image.Image) error {
+	return png.Encode(w, img)
+}
+
+// WritePPM writes img as a binary (P6) PPM with 8-bit channels.
+func WritePPM(w io.Writer, img image.Image) error {
+	var bounds = img.Bounds()
+	var bw = bufio.NewWriter(w)
+	if _, err := fmt.Fprintf(bw, "P6\n%d %d\n255\n", bounds.Dx(), bounds.Dy()); err != nil {
+		return err
+	}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			var r, g, b, _ = img.At(x, y).RGBA()
+			if _, err := bw.Write([]byte{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8)}); err != nil {
+				return err
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// SaveScreenshot captures rr and writes it to path, picking PNG or PPM from
+// the file extension.
+func SaveScreenshot(rr interfaces.ReadBackRenderer, path string, scale uint32) error {
+	var write func(w io.Writer, img image.Image) error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".png":
+		write = WritePNG
+	case ".ppm":
+		write = WritePPM
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownScreenshotFormat, filepath.Ext(path))
+	}
+	var img, err = CaptureFrame(rr, scale)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(file, img); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
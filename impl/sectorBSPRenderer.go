@@ -0,0 +1,91 @@
+package impl
+
+import (
+	"github.com/averseabfun/flux/interfaces"
+	"github.com/averseabfun/flux/types"
+)
+
+// SectorBSPRenderer draws a sector world by walking its node tree front to
+// back from the camera, stopping as soon as every screen column is filled.
+// If Tree is nil, or was built for another world, it is built on first use.
+type SectorBSPRenderer struct {
+	rr  interfaces.RawRenderer
+	FOV types.Degree
+	// Colormap shades the world by distance and sector light when set,
+	// reaching the darkest level at LightDistance (or SectorDefaultLightDistance).
+	Colormap      *Colormap
+	LightDistance float64
+	Tree          *types.BSPTree
+
+	treeWorld *types.WorldSector
+}
+
+func (sbr SectorBSPRenderer) Parent() interfaces.RawRenderer {
+	return sbr.rr
+}
+
+func (sbr *SectorBSPRenderer) SetParent(rr interfaces.RawRenderer) {
+	sbr.rr = rr
+}
+
+func (sbr SectorBSPRenderer) CanUseCurrentRawRenderer() bool {
+	return true
+}
+
+func (sbr *SectorBSPRenderer) RenderSectors(world *types.WorldSector, cameraPos types.Point3D, cameraRotation types.Degree) {
+	if sbr.Tree == nil || (sbr.treeWorld != nil && sbr.treeWorld != world) || sbr.Tree.Linedefs != len(world.Linedefs) {
+		sbr.Tree = BuildBSP(world)
+	}
+	sbr.treeWorld = world
+
+	var fov = sbr.FOV
+	if fov == 0 {
+		fov = SectorDefaultFOV
+	}
+	var view = newSectorView(world, cameraPos, cameraRotation, fov, sbr.rr.GetSize())
+	view.setLighting(sbr.Colormap, sbr.LightDistance)
+	if len(sbr.Tree.Subsectors) > 0 {
+		sbr.renderChild(view, sbr.Tree.Root)
+	}
+}
+
+func (sbr *SectorBSPRenderer) renderChild(view *sectorView, child types.BSPChild) {
+	if view.open == 0 {
+		return
+	}
+	if child.IsSubsector() {
+		var subsector = sbr.Tree.Subsectors[child.Index()]
+		for _, seg := range sbr.Tree.Segs[subsector.FirstSeg : subsector.FirstSeg+subsector.NumSegs] {
+			sbr.renderSeg(view, seg)
+		}
+		return
+	}
+	var node = sbr.Tree.Nodes[child.Index()]
+	if node.OnFront(view.pos.X, view.pos.Y) {
+		sbr.renderChild(view, node.Front)
+		sbr.renderChild(view, node.Back)
+	} else {
+		sbr.renderChild(view, node.Back)
+		sbr.renderChild(view, node.Front)
+	}
+}
+
+func (sbr *SectorBSPRenderer) renderSeg(view *sectorView, seg types.Seg) {
+	if !view.onFront(seg.Start, seg.End) {
+		return
+	}
+	var first, last, ok = view.segColumns(seg.Start, seg.End)
+	if !ok {
+		return
+	}
+	var line = view.world.Linedefs[seg.Linedef]
+	for column := first; column <= last; column++ {
+		if !view.isOpen(column) {
+			continue
+		}
+		var dist, along, hit = view.intersect(column, seg.Start, seg.End)
+		if hit {
+			view.drawLine(sbr.rr, column, dist, seg.Offset+along, line, seg.Front)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package impl
+
+import (
+	"math"
+
+	"github.com/averseabfun/flux/interfaces"
+	"github.com/averseabfun/flux/types"
+)
+
+var SectorDefaultFOV types.Degree = 90
+var SectorTextureSize float64 = 64
+var SectorDefaultLightDistance float64 = 1024
+var SectorNearClip float64 = 0.01
+
+// sectorView holds the camera and the per-column clipping windows for one
+// frame of a sector world. Lines must be drawn front to back; each one fills
+// the floor and ceiling in front of it, draws its walls and narrows the
+// window of rows still left open behind it.
+type sectorView struct {
+	world   *types.WorldSector
+	pos     types.Point3D
+	dirX    float64
+	dirY    float64
+	planeX  float64
+	planeY  float64
+	size    types.Point
+	focal   float64
+	horizon float64
+
+	top    []int
+	bottom []int
+	open   int
+
+	colormap      *Colormap
+	lightDistance float64
+}
+
+func newSectorView(world *types.WorldSector, pos types.Point3D, rotation types.Degree, fov types.Degree, size types.Point) *sectorView {
+	var rads = float64(rotation.ToRadians())
+	var planeLength = math.Tan(float64((fov / 2).ToRadians()))
+	var out = &sectorView{
+		world:   world,
+		pos:     pos,
+		dirX:    math.Cos(rads),
+		dirY:    math.Sin(rads),
+		size:    size,
+		focal:   float64(size.X) / 2 / planeLength,
+		horizon: float64(size.Y) / 2,
+		top:     make([]int, size.X),
+		bottom:  make([]int, size.X),
+		open:    int(size.X),
+	}
+	// Y grows upward in sector worlds, so the right of the screen is the
+	// view direction turned clockwise.
+	out.planeX, out.planeY = out.dirY*planeLength, -out.dirX*planeLength
+	for x := range out.bottom {
+		out.bottom[x] = int(size.Y) - 1
+	}
+	return out
+}
+
+func (sv *sectorView) setLighting(colormap *Colormap, lightDistance float64) {
+	sv.colormap = colormap
+	sv.lightDistance = lightDistance
+	if lightDistance == 0 {
+		sv.lightDistance = SectorDefaultLightDistance
+	}
+}
+
+func (sv *sectorView) shade(color types.PaletteIndex, darkness uint8, distance float64) types.PaletteIndex {
+	if sv.colormap == nil {
+		return color
+	}
+	return sv.colormap.Shade(color, sv.colormap.LightLevel(darkness, distance, sv.lightDistance))
+}
+
+func (sv *sectorView) rayDir(column int) (float64, float64) {
+	var cameraX = 2*(float64(column)+0.5)/float64(sv.size.X) - 1
+	return sv.dirX + sv.planeX*cameraX, sv.dirY + sv.planeY*cameraX
+}
+
+func (sv *sectorView) isOpen(column int) bool {
+	return sv.top[column] <= sv.bottom[column]
+}
+
+func (sv *sectorView) close(column int) {
+	if sv.isOpen(column) {
+		sv.open--
+	}
+	sv.top[column], sv.bottom[column] = int(sv.size.Y), -1
+}
+
+// columnOf is the screen column a world point projects to. Points closer
+// than the near plane are treated as lying on it.
+func (sv *sectorView) columnOf(x float64, y float64) float64 {
+	var relX, relY = x - sv.pos.X, y - sv.pos.Y
+	var depth = max(relX*sv.dirX+relY*sv.dirY, SectorNearClip)
+	var planeLengthSq = sv.planeX*sv.planeX + sv.planeY*sv.planeY
+	var cameraX = (relX*sv.planeX + relY*sv.planeY) / planeLengthSq / depth
+	return (cameraX + 1) * float64(sv.size.X) / 2
+}
+
+// intersect finds where the column's ray crosses the segment from a to b,
+// returning the distance along the view direction and the distance from a.
+func (sv *sectorView) intersect(column int, a types.Vertex, b types.Vertex) (float64, float64, bool) {
+	var rayX, rayY = sv.rayDir(column)
+	var segX, segY = b.X - a.X, b.Y - a.Y
+	var denom = rayX*segY - rayY*segX
+	if denom == 0 {
+		return 0, 0, false
+	}
+	var diffX, diffY = a.X - sv.pos.X, a.Y - sv.pos.Y
+	var dist = (diffX*segY - diffY*segX) / denom
+	var s = (diffX*rayY - diffY*rayX) / denom
+	if dist < SectorNearClip || s < 0 || s > 1 {
+		return 0, 0, false
+	}
+	return dist, s * math.Hypot(segX, segY), true
+}
+
+// onFront reports whether the camera is on the front (right) side of the
+// line from a to b.
+func (sv *sectorView) onFront(a types.Vertex, b types.Vertex) bool {
+	return (b.X-a.X)*(sv.pos.Y-a.Y)-(b.Y-a.Y)*(sv.pos.X-a.X) < 0
+}
+
+func (sv *sectorView) screenY(height float64, dist float64) float64 {
+	return sv.horizon - (height-sv.pos.Z)*sv.focal/dist
+}
+
+// rowAt is the first row whose centre lies at or below the screen position y.
+func rowAt(y float64) int {
+	return int(math.Ceil(y - 0.5))
+}
+
+func wrapUnit(v float64) float64 {
+	return v - math.Floor(v)
+}
+
+// drawLine draws what the column sees of a line between start and end at
+// dist, where along is the distance of the crossing from start. The line is
+// seen from its front when front is true.
+func (sv *sectorView) drawLine(rr interfaces.RawRenderer, column int, dist float64, along float64, line types.Linedef, front bool) {
+	if !sv.isOpen(column) {
+		return
+	}
+	var facing, behind = line.Front, line.Back
+	if !front {
+		facing, behind = line.Back, line.Front
+	}
+	if facing == types.NoSidedef {
+		return
+	}
+	var side = &sv.world.Sidedefs[facing]
+	var sector = &sv.world.Sectors[side.Sector]
+	var top, bottom = sv.top[column], sv.bottom[column]
+	var ceilingRow = rowAt(sv.screenY(sector.CeilingHeight, dist))
+	var floorRow = rowAt(sv.screenY(sector.FloorHeight, dist))
+
+	sv.drawFlat(rr, column, top, min(ceilingRow-1, bottom), sector.CeilingHeight, sector.CeilingColor, sector.CeilingSampler, sector.Darkness)
+	sv.drawFlat(rr, column, max(floorRow, top), bottom, sector.FloorHeight, sector.FloorColor, sector.FloorSampler, sector.Darkness)
+
+	if behind == types.NoSidedef {
+		sv.drawWallPart(rr, column, max(ceilingRow, top), min(floorRow-1, bottom), dist, along, side, side.Middle, sector.CeilingHeight, sector.Darkness)
+		sv.close(column)
+		return
+	}
+
+	var backSector = &sv.world.Sectors[sv.world.Sidedefs[behind].Sector]
+	var backCeilingRow = rowAt(sv.screenY(backSector.CeilingHeight, dist))
+	var backFloorRow = rowAt(sv.screenY(backSector.FloorHeight, dist))
+	if backSector.CeilingHeight < sector.CeilingHeight {
+		sv.drawWallPart(rr, column, max(ceilingRow, top), min(backCeilingRow-1, bottom), dist, along, side, side.Upper, sector.CeilingHeight, sector.Darkness)
+	}
+	if backSector.FloorHeight > sector.FloorHeight {
+		sv.drawWallPart(rr, column, max(backFloorRow, top), min(floorRow-1, bottom), dist, along, side, side.Lower, backSector.FloorHeight, sector.Darkness)
+	}
+
+	top, bottom = max(top, ceilingRow, backCeilingRow), min(bottom, floorRow-1, backFloorRow-1)
+	if top > bottom {
+		sv.close(column)
+		return
+	}
+	sv.top[column], sv.bottom[column] = top, bottom
+}
+
+// drawWallPart fills rows from y0 to y1 with a wall whose texture is anchored
+// at the world height anchor.
+func (sv *sectorView) drawWallPart(rr interfaces.RawRenderer, column int, y0 int, y1 int, dist float64, along float64, side *types.Sidedef, sampler types.Sampler, anchor float64, darkness uint8) {
+	if sampler == nil {
+		for y := y0; y <= y1; y++ {
+			rr.DrawBackPixel(uint32(column), uint32(y), sv.shade(side.Color, darkness, dist))
+		}
+		return
+	}
+	var point = types.SamplerPoint{X: wrapUnit((along + side.OffsetX) / SectorTextureSize)}
+	for y := y0; y <= y1; y++ {
+		var height = sv.pos.Z + (sv.horizon-float64(y)-0.5)*dist/sv.focal
+		point.Y = wrapUnit((anchor - height + side.OffsetY) / SectorTextureSize)
+		rr.DrawBackPixel(uint32(column), uint32(y), sv.shade(sampler.GetAtPoint(point), darkness, dist))
+	}
+}
+
+// drawFlat fills rows from y0 to y1 with a floor or ceiling at height.
+func (sv *sectorView) drawFlat(rr interfaces.RawRenderer, column int, y0 int, y1 int, height float64, color types.PaletteIndex, sampler types.Sampler, darkness uint8) {
+	var rayX, rayY = sv.rayDir(column)
+	for y := y0; y <= y1; y++ {
+		var dist = (height - sv.pos.Z) * sv.focal / (sv.horizon - float64(y) - 0.5)
+		if dist <= 0 || math.IsInf(dist, 0) {
+			rr.DrawBackPixel(uint32(column), uint32(y), sv.shade(color, darkness, 0))
+			continue
+		}
+		var out = color
+		if sampler != nil {
+			out = sampler.GetAtPoint(types.SamplerPoint{
+				X: wrapUnit((sv.pos.X + rayX*dist) / SectorTextureSize),
+				Y: wrapUnit((sv.pos.Y + rayY*dist) / SectorTextureSize),
+			})
+		}
+		rr.DrawBackPixel(uint32(column), uint32(y), sv.shade(out, darkness, dist))
+	}
+}
+
+// segColumns is the range of screen columns the segment from a to b covers,
+// after clipping it against the near plane.
+func (sv *sectorView) segColumns(a types.Vertex, b types.Vertex) (int, int, bool) {
+	var depthA = (a.X-sv.pos.X)*sv.dirX + (a.Y-sv.pos.Y)*sv.dirY
+	var depthB = (b.X-sv.pos.X)*sv.dirX + (b.Y-sv.pos.Y)*sv.dirY
+	if depthA < SectorNearClip && depthB < SectorNearClip {
+		return 0, 0, false
+	}
+	if depthA < SectorNearClip {
+		var t = (SectorNearClip - depthA) / (depthB - depthA)
+		a = types.Vertex{X: a.X + (b.X-a.X)*t, Y: a.Y + (b.Y-a.Y)*t}
+	} else if depthB < SectorNearClip {
+		var t = (SectorNearClip - depthB) / (depthA - depthB)
+		b = types.Vertex{X: b.X + (a.X-b.X)*t, Y: b.Y + (a.Y-b.Y)*t}
+	}
+	var columnA, columnB = sv.columnOf(a.X, a.Y), sv.columnOf(b.X, b.Y)
+	var first = max(int(math.Floor(min(columnA, columnB))), 0)
+	var last = min(int(math.Ceil(max(columnA, columnB))), int(sv.size.X)-1)
+	return first, last, first <= last
+}
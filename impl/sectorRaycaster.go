@@ -0,0 +1,70 @@
+package impl
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/averseabfun/flux/interfaces"
+	"github.com/averseabfun/flux/types"
+)
+
+type sectorCrossing struct {
+	line  int
+	dist  float64
+	along float64
+}
+
+// SectorRaycaster draws a sector world by crossing every linedef with the ray
+// of each screen column and drawing the crossings nearest first.
+type SectorRaycaster struct {
+	rr  interfaces.RawRenderer
+	FOV types.Degree
+	// Colormap shades the world by distance and sector light when set,
+	// reaching the darkest level at LightDistance (or SectorDefaultLightDistance).
+	Colormap      *Colormap
+	LightDistance float64
+
+	crossings []sectorCrossing
+}
+
+func (sr SectorRaycaster) Parent() interfaces.RawRenderer {
+	return sr.rr
+}
+
+func (sr *SectorRaycaster) SetParent(rr interfaces.RawRenderer) {
+	sr.rr = rr
+}
+
+func (sr SectorRaycaster) CanUseCurrentRawRenderer() bool {
+	return true
+}
+
+func (sr *SectorRaycaster) RenderSectors(world *types.WorldSector, cameraPos types.Point3D, cameraRotation types.Degree) {
+	var fov = sr.FOV
+	if fov == 0 {
+		fov = SectorDefaultFOV
+	}
+	var view = newSectorView(world, cameraPos, cameraRotation, fov, sr.rr.GetSize())
+	view.setLighting(sr.Colormap, sr.LightDistance)
+
+	for column := 0; column < int(view.size.X); column++ {
+		sr.crossings = sr.crossings[:0]
+		for i, line := range world.Linedefs {
+			var dist, along, ok = view.intersect(column, world.Vertices[line.Start], world.Vertices[line.End])
+			if ok {
+				sr.crossings = append(sr.crossings, sectorCrossing{line: i, dist: dist, along: along})
+			}
+		}
+		slices.SortFunc(sr.crossings, func(a sectorCrossing, b sectorCrossing) int {
+			return cmp.Compare(a.dist, b.dist)
+		})
+		for _, crossing := range sr.crossings {
+			if !view.isOpen(column) {
+				break
+			}
+			var line = world.Linedefs[crossing.line]
+			var front = view.onFront(world.Vertices[line.Start], world.Vertices[line.End])
+			view.drawLine(sr.rr, column, crossing.dist, crossing.along, line, front)
+		}
+	}
+}
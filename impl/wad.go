@@ -0,0 +1,372 @@
+package impl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/averseabfun/flux/interfaces"
+	"github.com/averseabfun/flux/types"
+)
+
+var ErrNotWAD = errors.New("not a WAD file")
+var ErrLumpNotFound = errors.New("lump not found")
+var ErrUnsupportedLump = errors.New("unsupported lump")
+
+// WADPlayerStartThing is the thing type of player one's start.
+var WADPlayerStartThing uint16 = 1
+
+// WADEyeHeight is how far above the floor the player's eyes are, in map units.
+var WADEyeHeight float64 = 41
+
+const (
+	wadHeaderSize   = 12
+	wadDirEntrySize = 16
+	wadPaletteSize  = 256 * 3
+
+	wadThingSize   = 10
+	wadLinedefSize = 14
+	wadSidedefSize = 30
+	wadVertexSize  = 4
+	wadSectorSize  = 26
+)
+
+// wadMapLumps are the lumps that may follow a map marker, in order.
+var wadMapLumps = []string{"THINGS", "LINEDEFS", "SIDEDEFS", "VERTEXES", "SEGS", "SSECTORS", "NODES", "SECTORS", "REJECT", "BLOCKMAP", "BEHAVIOR"}
+
+type WADLump struct {
+	Name   string
+	Offset int
+	Size   int
+}
+
+// WAD is an IWAD or PWAD held in memory. Later lumps override earlier ones
+// of the same name, as when a PWAD is loaded over an IWAD.
+type WAD struct {
+	Type  string
+	Lumps []WADLump
+	data  []byte
+}
+
+func LoadWAD(path string) (*WAD, error) {
+	var data, err = os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	wad, err := ReadWAD(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return wad, nil
+}
+
+func ReadWAD(data []byte) (*WAD, error) {
+	if len(data) < wadHeaderSize {
+		return nil, fmt.Errorf("%w: file is only %d bytes", ErrNotWAD, len(data))
+	}
+	var out = &WAD{Type: string(data[:4]), data: data}
+	if out.Type != "IWAD" && out.Type != "PWAD" {
+		return nil, fmt.Errorf("%w: bad magic %q", ErrNotWAD, data[:4])
+	}
+	var numLumps = int(int32(binary.LittleEndian.Uint32(data[4:])))
+	var dirOffset = int(int32(binary.LittleEndian.Uint32(data[8:])))
+	if numLumps < 0 || dirOffset < 0 || dirOffset+numLumps*wadDirEntrySize > len(data) {
+		return nil, fmt.Errorf("%w: directory of %d lumps at %d is out of range", ErrNotWAD, numLumps, dirOffset)
+	}
+	out.Lumps = make([]WADLump, numLumps)
+	for i := range out.Lumps {
+		var entry = data[dirOffset+i*wadDirEntrySize:]
+		var lump = WADLump{
+			Offset: int(int32(binary.LittleEndian.Uint32(entry))),
+			Size:   int(int32(binary.LittleEndian.Uint32(entry[4:]))),
+			Name:   wadName(entry[8:16]),
+		}
+		if lump.Offset < 0 || lump.Size < 0 || lump.Offset+lump.Size > len(data) {
+			return nil, fmt.Errorf("%w: lump %d (%s) is out of range", ErrNotWAD, i, lump.Name)
+		}
+		out.Lumps[i] = lump
+	}
+	return out, nil
+}
+
+func wadName(raw []byte) string {
+	if end := bytes.IndexByte(raw, 0); end >= 0 {
+		raw = raw[:end]
+	}
+	return strings.ToUpper(string(raw))
+}
+
+// FindLump returns the index of the last lump called name, or -1.
+func (w *WAD) FindLump(name string) int {
+	name = strings.ToUpper(name)
+	for i := len(w.Lumps) - 1; i >= 0; i-- {
+		if w.Lumps[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (w *WAD) LumpData(index int) []byte {
+	var lump = w.Lumps[index]
+	return w.data[lump.Offset : lump.Offset+lump.Size]
+}
+
+// Lump returns the contents of the last lump called name.
+func (w *WAD) Lump(name string) ([]byte, error) {
+	var index = w.FindLump(name)
+	if index < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrLumpNotFound, name)
+	}
+	return w.LumpData(index), nil
+}
+
+// MapNames lists the map markers in the WAD, such as E1M1 or MAP01.
+func (w *WAD) MapNames() []string {
+	var out []string
+	for i := 0; i+1 < len(w.Lumps); i++ {
+		if w.Lumps[i+1].Name == "THINGS" || w.Lumps[i+1].Name == "TEXTMAP" {
+			out = append(out, w.Lumps[i].Name)
+		}
+	}
+	return out
+}
+
+// mapLumps finds the lumps belonging to the map with the given marker.
+func (w *WAD) mapLumps(name string) (map[string][]byte, error) {
+	var marker = w.FindLump(name)
+	if marker < 0 {
+		return nil, fmt.Errorf("%w: map %s", ErrLumpNotFound, name)
+	}
+	var out = make(map[string][]byte)
+	for i := marker + 1; i < len(w.Lumps); i++ {
+		var lumpName = w.Lumps[i].Name
+		if lumpName == "TEXTMAP" {
+			return nil, fmt.Errorf("%w: map %s is in UDMF (TEXTMAP) format", ErrUnsupportedLump, name)
+		}
+		if !strings.HasPrefix(lumpName, "GL_") && !slices.Contains(wadMapLumps, lumpName) {
+			break
+		}
+		out[lumpName] = w.LumpData(i)
+	}
+	if _, ok := out["BEHAVIOR"]; ok {
+		return nil, fmt.Errorf("%w: map %s is in Hexen format (has a BEHAVIOR lump)", ErrUnsupportedLump, name)
+	}
+	for _, required := range []string{"THINGS", "LINEDEFS", "SIDEDEFS", "VERTEXES", "SECTORS"} {
+		if _, ok := out[required]; !ok {
+			return nil, fmt.Errorf("%w: map %s has no %s", ErrLumpNotFound, name, required)
+		}
+	}
+	return out, nil
+}
+
+func wadRecords(lumps map[string][]byte, name string, size int) ([]byte, int, error) {
+	var data = lumps[name]
+	if len(data)%size != 0 {
+		return nil, 0, fmt.Errorf("%w: %s is %d bytes, not a multiple of %d", ErrUnsupportedLump, name, len(data), size)
+	}
+	return data, len(data) / size, nil
+}
+
+func wadInt16(data []byte, offset int) float64 {
+	return float64(int16(binary.LittleEndian.Uint16(data[offset:])))
+}
+
+func wadIndex(data []byte, offset int) int {
+	var value = binary.LittleEndian.Uint16(data[offset:])
+	if value == 0xFFFF {
+		return types.NoSidedef
+	}
+	return int(value)
+}
+
+// LoadMap reads a Doom-format map into a sector world. Walls and flats get
+// flat colors: flats use their most common palette index, and wall textures,
+// which are built from patches, get a color derived from their name.
+func (w *WAD) LoadMap(name string) (*types.WorldSector, error) {
+	var lumps, err = w.mapLumps(strings.ToUpper(name))
+	if err != nil {
+		return nil, err
+	}
+	var out = &types.WorldSector{}
+
+	data, count, err := wadRecords(lumps, "VERTEXES", wadVertexSize)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < count; i++ {
+		out.Vertices = append(out.Vertices, types.Vertex{X: wadInt16(data, i*wadVertexSize), Y: wadInt16(data, i*wadVertexSize+2)})
+	}
+
+	data, count, err = wadRecords(lumps, "SECTORS", wadSectorSize)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < count; i++ {
+		var record = data[i*wadSectorSize:]
+		var light = int(int16(binary.LittleEndian.Uint16(record[20:])))
+		var sector = types.Sector{
+			FloorHeight:    wadInt16(record, 0),
+			CeilingHeight:  wadInt16(record, 2),
+			FloorTexture:   wadName(record[4:12]),
+			CeilingTexture: wadName(record[12:20]),
+			Darkness:       uint8(255 - min(max(light, 0), 255)),
+		}
+		sector.FloorColor = w.flatColor(sector.FloorTexture)
+		sector.CeilingColor = w.flatColor(sector.CeilingTexture)
+		out.Sectors = append(out.Sectors, sector)
+	}
+
+	data, count, err = wadRecords(lumps, "SIDEDEFS", wadSidedefSize)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < count; i++ {
+		var record = data[i*wadSidedefSize:]
+		var side = types.Sidedef{
+			OffsetX:       wadInt16(record, 0),
+			OffsetY:       wadInt16(record, 2),
+			UpperTexture:  wadName(record[4:12]),
+			LowerTexture:  wadName(record[12:20]),
+			MiddleTexture: wadName(record[20:28]),
+			Sector:        int(binary.LittleEndian.Uint16(record[28:])),
+		}
+		for _, texture := range []string{side.MiddleTexture, side.UpperTexture, side.LowerTexture} {
+			if texture != "" && texture != "-" {
+				side.Color = wadTextureColor(texture)
+				break
+			}
+		}
+		out.Sidedefs = append(out.Sidedefs, side)
+	}
+
+	data, count, err = wadRecords(lumps, "LINEDEFS", wadLinedefSize)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < count; i++ {
+		var record = data[i*wadLinedefSize:]
+		out.Linedefs = append(out.Linedefs, types.Linedef{
+			Start: int(binary.LittleEndian.Uint16(record)),
+			End:   int(binary.LittleEndian.Uint16(record[2:])),
+			Front: wadIndex(record, 10),
+			Back:  wadIndex(record, 12),
+		})
+	}
+
+	data, count, err = wadRecords(lumps, "THINGS", wadThingSize)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < count; i++ {
+		var record = data[i*wadThingSize:]
+		out.Things = append(out.Things, types.Thing{
+			X:     wadInt16(record, 0),
+			Y:     wadInt16(record, 2),
+			Angle: types.Degree(wadInt16(record, 4)),
+			Type:  binary.LittleEndian.Uint16(record[6:]),
+			Flags: binary.LittleEndian.Uint16(record[8:]),
+		})
+	}
+
+	if err = out.Validate(); err != nil {
+		return nil, fmt.Errorf("map %s: %w", name, err)
+	}
+	return out, nil
+}
+
+// WADPlayerStart finds player one's start in the world, with Z at eye height
+// above the floor of the sector it stands in.
+func WADPlayerStart(world *types.WorldSector) (types.Point3D, types.Degree, bool) {
+	for _, thing := range world.Things {
+		if thing.Type != WADPlayerStartThing {
+			continue
+		}
+		var out = types.Point3D{X: thing.X, Y: thing.Y, Z: WADEyeHeight}
+		if sector, ok := SectorAt(world, thing.X, thing.Y); ok {
+			out.Z += world.Sectors[sector].FloorHeight
+		}
+		return out, thing.Angle, true
+	}
+	return types.Point3D{}, 0, false
+}
+
+// SectorAt finds the sector containing a point by casting a ray along +X and
+// taking the side of the nearest linedef it crosses.
+func SectorAt(world *types.WorldSector, x float64, y float64) (int, bool) {
+	var nearest = -1.0
+	var sector = -1
+	for _, line := range world.Linedefs {
+		var a, b = world.Vertices[line.Start], world.Vertices[line.End]
+		if (a.Y > y) == (b.Y > y) {
+			continue
+		}
+		var crossX = a.X + (y-a.Y)*(b.X-a.X)/(b.Y-a.Y)
+		if crossX < x || (nearest >= 0 && crossX-x >= nearest) {
+			continue
+		}
+		var side = line.Front
+		if (b.X-a.X)*(y-a.Y)-(b.Y-a.Y)*(x-a.X) > 0 {
+			side = line.Back
+		}
+		nearest = crossX - x
+		sector = -1
+		if side != types.NoSidedef {
+			sector = world.Sidedefs[side].Sector
+		}
+	}
+	return sector, sector >= 0
+}
+
+func (w *WAD) flatColor(name string) types.PaletteIndex {
+	var index = w.FindLump(name)
+	if index < 0 || w.Lumps[index].Size == 0 {
+		return wadTextureColor(name)
+	}
+	var counts [256]int
+	var best byte
+	for _, pixel := range w.LumpData(index) {
+		counts[pixel]++
+		if counts[pixel] > counts[best] {
+			best = pixel
+		}
+	}
+	return types.PaletteIndex(best)
+}
+
+func wadTextureColor(name string) types.PaletteIndex {
+	var hash = fnv.New32a()
+	hash.Write([]byte(name))
+	return types.PaletteIndex(hash.Sum32()%255 + 1)
+}
+
+// Palette reads palette number n (0 is the normal palette) from PLAYPAL.
+func (w *WAD) Palette(n int) (types.Palette, error) {
+	var data, err = w.Lump("PLAYPAL")
+	if err != nil {
+		return types.Palette{}, err
+	}
+	if n < 0 || (n+1)*wadPaletteSize > len(data) {
+		return types.Palette{}, fmt.Errorf("%w: PLAYPAL has no palette %d", ErrLumpNotFound, n)
+	}
+	var out types.Palette
+	data = data[n*wadPaletteSize:]
+	for i := range out {
+		out[i] = types.FromRGB8(data[i*3], data[i*3+1], data[i*3+2])
+	}
+	return out, nil
+}
+
+// ApplyWADPalette sets every palette entry of rr from the WAD's first PLAYPAL palette.
+func ApplyWADPalette(rr interfaces.RawRenderer, w *WAD) error {
+	var palette, err = w.Palette(0)
+	if err != nil {
+		return err
+	}
+	return ApplyPalette(rr, palette)
+}
@@ -0,0 +1,254 @@
+package impl
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+
+	"github.com/averseabfun/flux/types"
+)
+
+// The binary format is little-endian throughout: the magic, a uint16
+// version, then the world settings, palette, walls and sprites, each list
+// prefixed by its length. Samplers are stored as their text spec, prefixed
+// by a uint16 length, with an empty spec meaning no sampler; sprites must
+// have one.
+
+var wolfBinaryMagic = []byte("FLXB")
+
+const wolfBinarySideCount = 4
+
+type WolfBinaryCodec struct{}
+
+func (wbc *WolfBinaryCodec) Name() string {
+	return "binary"
+}
+
+func (wbc *WolfBinaryCodec) Extensions() []string {
+	return []string{".fluxbin"}
+}
+
+func (wbc *WolfBinaryCodec) Magic() []byte {
+	return wolfBinaryMagic
+}
+
+type binaryWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (bw *binaryWriter) write(value any) {
+	if bw.err == nil {
+		bw.err = binary.Write(bw.w, binary.LittleEndian, value)
+	}
+}
+
+func (bw *binaryWriter) writeSampler(sampler types.Sampler) {
+	var spec string
+	if sampler != nil && bw.err == nil {
+		spec, bw.err = FormatWolfSampler(sampler)
+	}
+	if len(spec) > math.MaxUint16 {
+		bw.err = fmt.Errorf("sampler spec is too long (%d bytes)", len(spec))
+	}
+	bw.write(uint16(len(spec)))
+	bw.write([]byte(spec))
+}
+
+func (bw *binaryWriter) writeSurface(surface types.WolfSurface) {
+	bw.write(surface.Enabled)
+	bw.write(surface.Color)
+	bw.write(surface.Scale)
+	bw.writeSampler(surface.Sampler)
+}
+
+func (wbc *WolfBinaryCodec) Encode(w io.Writer, world types.WorldWolf) error {
+	var bw = &binaryWriter{w: bufio.NewWriter(w)}
+	bw.write(wolfBinaryMagic)
+	bw.write(uint16(WolfMapVersion))
+	bw.write(world.Darkness)
+	bw.write([3]float64{world.PlayerStart.X, world.PlayerStart.Y, float64(world.PlayerHeading)})
+	bw.writeSurface(world.Floor)
+	bw.writeSurface(world.Ceiling)
+
+	var indices = make([]types.PaletteIndex, 0, len(world.Palette))
+	for index := range world.Palette {
+		indices = append(indices, index)
+	}
+	slices.Sort(indices)
+	bw.write(uint16(len(indices)))
+	for _, index := range indices {
+		var color = world.Palette[index]
+		bw.write([4]uint8{uint8(index), uint8(color.R), uint8(color.G), uint8(color.B)})
+	}
+
+	bw.write(uint32(len(world.Objects)))
+	for _, id := range sortedIDs(world.Objects) {
+		var object = world.Objects[id]
+		bw.write(uint64(object.ID))
+		bw.write(object.Color)
+		bw.write([4]uint32{object.Start.X, object.Start.Y, object.End.X, object.End.Y})
+		bw.write(object.Darkness)
+		bw.writeSampler(object.Sampler)
+		for side := types.Side(0); side < wolfBinarySideCount; side++ {
+			bw.writeSampler(object.SideSamplers[side])
+		}
+	}
+
+	bw.write(uint32(len(world.Sprites)))
+	for _, id := range sortedIDs(world.Sprites) {
+		var sprite = world.Sprites[id]
+		if sprite.Sampler == nil && bw.err == nil {
+			bw.err = fmt.Errorf("sprite %d: has no sampler", id)
+		}
+		bw.write(uint64(sprite.ID))
+		bw.write([4]float64{sprite.Position.X, sprite.Position.Y, sprite.Width, sprite.Height})
+		bw.write(sprite.HasTransparency)
+		bw.write(sprite.Transparent)
+		bw.write(sprite.Darkness)
+		bw.writeSampler(sprite.Sampler)
+	}
+	if bw.err != nil {
+		return bw.err
+	}
+	return bw.w.Flush()
+}
+
+type binaryReader struct {
+	r   io.Reader
+	err error
+}
+
+func (br *binaryReader) read(value any) {
+	if br.err == nil {
+		br.err = binary.Read(br.r, binary.LittleEndian, value)
+	}
+}
+
+func (br *binaryReader) readSampler() types.Sampler {
+	var length uint16
+	br.read(&length)
+	var spec = make([]byte, length)
+	br.read(spec)
+	if br.err != nil || length == 0 {
+		return nil
+	}
+	var sampler types.Sampler
+	sampler, br.err = ParseWolfSampler(string(spec))
+	return sampler
+}
+
+func (br *binaryReader) readSurface() types.WolfSurface {
+	var out types.WolfSurface
+	br.read(&out.Enabled)
+	br.read(&out.Color)
+	br.read(&out.Scale)
+	out.Sampler = br.readSampler()
+	return out
+}
+
+func (wbc *WolfBinaryCodec) Decode(r io.Reader, name string) (types.WorldWolf, error) {
+	var out = newWorldWolf()
+	var br = &binaryReader{r: bufio.NewReader(r)}
+	var magic = make([]byte, len(wolfBinaryMagic))
+	var version uint16
+	br.read(magic)
+	br.read(&version)
+	if br.err != nil {
+		return *out, fmt.Errorf("%s: %w", name, br.err)
+	}
+	if string(magic) != string(wolfBinaryMagic) {
+		return *out, fmt.Errorf("%s: %w: bad magic %q", name, ErrUnknownWorldFormat, magic)
+	}
+	if version != WolfMapVersion {
+		return *out, fmt.Errorf("%s: %w %d (expected %d)", name, ErrUnsupportedMapVersion, version, WolfMapVersion)
+	}
+
+	var player [3]float64
+	br.read(&out.Darkness)
+	br.read(&player)
+	out.PlayerStart = types.SamplerPoint{X: player[0], Y: player[1]}
+	out.PlayerHeading = types.Degree(player[2])
+	out.Floor = br.readSurface()
+	out.Ceiling = br.readSurface()
+
+	var paletteCount uint16
+	br.read(&paletteCount)
+	for i := 0; i < int(paletteCount) && br.err == nil; i++ {
+		var entry [4]uint8
+		br.read(&entry)
+		var color, err = types.FromRGBUint8(entry[1], entry[2], entry[3])
+		if err != nil && br.err == nil {
+			br.err = fmt.Errorf("palette entry %d: %w", entry[0], err)
+		}
+		if out.Palette == nil {
+			out.Palette = make(map[types.PaletteIndex]types.Color)
+		}
+		out.Palette[types.PaletteIndex(entry[0])] = color
+	}
+
+	var wallCount uint32
+	br.read(&wallCount)
+	for i := 0; i < int(wallCount) && br.err == nil; i++ {
+		var id uint64
+		var bounds [4]uint32
+		var object = &types.RectWolf{}
+		br.read(&id)
+		br.read(&object.Color)
+		br.read(&bounds)
+		br.read(&object.Darkness)
+		object.ID = types.ObjectID(id)
+		object.Start = types.Point{X: bounds[0], Y: bounds[1]}
+		object.End = types.Point{X: bounds[2], Y: bounds[3]}
+		object.Sampler = br.readSampler()
+		for side := types.Side(0); side < wolfBinarySideCount; side++ {
+			if sampler := br.readSampler(); sampler != nil {
+				if object.SideSamplers == nil {
+					object.SideSamplers = make(map[types.Side]types.Sampler)
+				}
+				object.SideSamplers[side] = sampler
+			}
+		}
+		if br.err == nil && (object.End.X < object.Start.X || object.End.Y < object.Start.Y) {
+			br.err = fmt.Errorf("wall %d ends before it starts", id)
+		}
+		if br.err == nil {
+			br.err = addWall(out, object)
+		}
+	}
+
+	var spriteCount uint32
+	br.read(&spriteCount)
+	for i := 0; i < int(spriteCount) && br.err == nil; i++ {
+		var id uint64
+		var values [4]float64
+		var sprite = &types.SpriteWolf{World: out}
+		br.read(&id)
+		br.read(&values)
+		br.read(&sprite.HasTransparency)
+		br.read(&sprite.Transparent)
+		br.read(&sprite.Darkness)
+		sprite.ID = types.ObjectID(id)
+		sprite.Position = types.SamplerPoint{X: values[0], Y: values[1]}
+		sprite.Width, sprite.Height = values[2], values[3]
+		sprite.Sampler = br.readSampler()
+		if sprite.Sampler == nil && br.err == nil {
+			br.err = fmt.Errorf("sprite %d: has no sampler", id)
+		}
+		if _, ok := out.Sprites[sprite.ID]; ok && br.err == nil {
+			br.err = fmt.Errorf("duplicate sprite id %d", id)
+		}
+		out.Sprites[sprite.ID] = sprite
+	}
+	if errors.Is(br.err, io.EOF) {
+		br.err = io.ErrUnexpectedEOF
+	}
+	if br.err != nil {
+		return *out, fmt.Errorf("%s: %w", name, br.err)
+	}
+	return *out, nil
+}
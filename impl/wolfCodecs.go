@@ -0,0 +1,111 @@
+package impl
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/averseabfun/flux/interfaces"
+	"github.com/averseabfun/flux/types"
+)
+
+var ErrUnknownWorldFormat = errors.New("unknown world format")
+
+var wolfCodecs []interfaces.WolfWorldCodec
+
+func init() {
+	RegisterWolfCodec(&WolfTextCodec{})
+	RegisterWolfCodec(&WolfJSONCodec{})
+	RegisterWolfCodec(&WolfBinaryCodec{})
+}
+
+// RegisterWolfCodec adds codec to the formats LoadWolfWorld and SaveWolfWorld
+// know about. Codecs registered later take precedence.
+func RegisterWolfCodec(codec interfaces.WolfWorldCodec) {
+	wolfCodecs = append(wolfCodecs, codec)
+}
+
+// WolfCodecForHeader returns the codec whose magic bytes header starts with.
+func WolfCodecForHeader(header []byte) (interfaces.WolfWorldCodec, error) {
+	for _, codec := range slices.Backward(wolfCodecs) {
+		if magic := codec.Magic(); len(magic) > 0 && bytes.HasPrefix(header, magic) {
+			return codec, nil
+		}
+	}
+	return nil, ErrUnknownWorldFormat
+}
+
+// WolfCodecForPath returns the codec registered for the extension of path.
+func WolfCodecForPath(path string) (interfaces.WolfWorldCodec, error) {
+	var ext = strings.ToLower(filepath.Ext(path))
+	for _, codec := range slices.Backward(wolfCodecs) {
+		if slices.Contains(codec.Extensions(), ext) {
+			return codec, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: no codec for %q files", ErrUnknownWorldFormat, ext)
+}
+
+// LoadWolfWorld loads a world from path, recognizing the format by its magic
+// bytes or, failing that, by its extension.
+func LoadWolfWorld(path string) (types.WorldWolf, error) {
+	var file, err = os.Open(path)
+	if err != nil {
+		return types.WorldWolf{}, err
+	}
+	defer file.Close()
+	var reader = bufio.NewReader(file)
+	var header, _ = reader.Peek(64)
+	codec, err := WolfCodecForHeader(header)
+	if err != nil {
+		if codec, err = WolfCodecForPath(path); err != nil {
+			return types.WorldWolf{}, err
+		}
+	}
+	return codec.Decode(reader, path)
+}
+
+// SaveWolfWorld writes world to path in the format registered for its extension.
+func SaveWolfWorld(path string, world types.WorldWolf) error {
+	var codec, err = WolfCodecForPath(path)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := codec.Encode(file, world); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+type WolfTextCodec struct{}
+
+func (wtc *WolfTextCodec) Name() string {
+	return "text"
+}
+
+func (wtc *WolfTextCodec) Extensions() []string {
+	return []string{".fluxmap", ".txt", ".csv"}
+}
+
+func (wtc *WolfTextCodec) Magic() []byte {
+	return []byte(wolfMapMagic)
+}
+
+func (wtc *WolfTextCodec) Decode(r io.Reader, name string) (types.WorldWolf, error) {
+	return ParseWolfWorld(r, name)
+}
+
+func (wtc *WolfTextCodec) Encode(w io.Writer, world types.WorldWolf) error {
+	return WriteWolfMap(w, world)
+}
@@ -0,0 +1,67 @@
+package impl
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/averseabfun/flux/interfaces"
+	"github.com/averseabfun/flux/types"
+)
+
+func TestWolfCodecRoundTrip(t *testing.T) {
+	for _, codec := range []interfaces.WolfWorldCodec{&WolfJSONCodec{}, &WolfBinaryCodec{}} {
+		for name, world := range roundTripWorlds() {
+			var buf bytes.Buffer
+			if err := codec.Encode(&buf, *world); err != nil {
+				t.Fatalf("%s %s: encode: %v", codec.Name(), name, err)
+			}
+			var got, err = codec.Decode(&buf, name)
+			if err != nil {
+				t.Fatalf("%s %s: decode: %v", codec.Name(), name, err)
+			}
+			if !reflect.DeepEqual(got, *world) {
+				t.Errorf("%s %s: decode(encode(w)) differs:\ngot  %+v\nwant %+v", codec.Name(), name, got, *world)
+			}
+		}
+	}
+}
+
+func TestWolfCodecSpriteWithoutSampler(t *testing.T) {
+	var world = newWorldWolf()
+	world.Sprites[1] = &types.SpriteWolf{ID: 1, World: world}
+	for _, codec := range []interfaces.WolfWorldCodec{&WolfJSONCodec{}, &WolfBinaryCodec{}} {
+		if err := codec.Encode(&bytes.Buffer{}, *world); err == nil {
+			t.Errorf("%s: encoded a sprite without a sampler", codec.Name())
+		}
+	}
+
+	var jsonWorld = fmt.Sprintf(`{"version": %d, "walls": [], "sprites": [{"id": 1, "position": [0, 0]}]}`, WolfMapVersion)
+	if _, err := (&WolfJSONCodec{}).Decode(strings.NewReader(jsonWorld), "json"); err == nil {
+		t.Error("json: decoded a sprite without a sampler")
+	}
+
+	// Write an empty world, then swap its sprite count for one sampler-less sprite.
+	var buf bytes.Buffer
+	if err := (&WolfBinaryCodec{}).Encode(&buf, *newWorldWolf()); err != nil {
+		t.Fatal(err)
+	}
+	buf.Truncate(buf.Len() - 4)
+	var bw = &binaryWriter{w: bufio.NewWriter(&buf)}
+	bw.write(uint32(1))
+	bw.write(uint64(1))
+	bw.write([4]float64{})
+	bw.write(false)
+	bw.write(types.PaletteIndex(0))
+	bw.write(uint8(0))
+	bw.writeSampler(nil)
+	if bw.err != nil || bw.w.Flush() != nil {
+		t.Fatal(bw.err)
+	}
+	if _, err := (&WolfBinaryCodec{}).Decode(&buf, "binary"); err == nil {
+		t.Error("binary: decoded a sprite without a sampler")
+	}
+}
@@ -0,0 +1,208 @@
+package impl
+
+import (
+	"cmp"
+	"math"
+	"slices"
+
+	"github.com/averseabfun/flux/interfaces"
+	"github.com/averseabfun/flux/types"
+)
+
+// WolfNearClip is the closest a wall is drawn from, in world units.
+var WolfNearClip float64 = 1e-3
+
+// WolfHit is what the ray cast for one screen column struck. Object is nil if
+// the ray left the world without hitting anything.
+type WolfHit struct {
+	Object *types.RectWolf
+	Side   types.Side
+	// Distance is measured along the view direction, so it is already
+	// corrected for fisheye; RayDistance is measured along the ray itself.
+	Distance    float64
+	RayDistance float64
+	At          types.SamplerPoint
+}
+
+// WolfHitRecorder is implemented by Wolf renderers that keep the hit for
+// every column of the last frame they rendered.
+type WolfHitRecorder interface {
+	LastHits() []WolfHit
+}
+
+// TextureX is the horizontal sampler coordinate of the hit across the struck
+// side, running left to right as seen by the camera.
+func (hit WolfHit) TextureX() float64 {
+	var object = hit.Object
+	var out float64
+	switch hit.Side {
+	case types.SideLeft, types.SideRight:
+		out = (hit.At.Y - float64(object.Start.Y)) / float64(object.End.Y+1-object.Start.Y)
+	default:
+		out = (hit.At.X - float64(object.Start.X)) / float64(object.End.X+1-object.Start.X)
+	}
+	if hit.Side == types.SideRight || hit.Side == types.SideTop {
+		out = 1 - out
+	}
+	return min(max(out, 0), 1)
+}
+
+type wolfView struct {
+	pos        types.SamplerPoint
+	dirX       float64
+	dirY       float64
+	planeX     float64
+	planeY     float64
+	size       types.Point
+	wallHeight float64
+
+	colormap      *Colormap
+	lightDistance float64
+}
+
+func newWolfView(pos types.SamplerPoint, rotation types.Degree, fov types.Degree, size types.Point, wallHeight float64) wolfView {
+	var rads = float64(rotation.ToRadians())
+	var planeLength = math.Tan(float64((fov / 2).ToRadians()))
+	var out = wolfView{pos: pos, dirX: math.Cos(rads), dirY: math.Sin(rads), size: size, wallHeight: wallHeight}
+	out.planeX, out.planeY = -out.dirY*planeLength, out.dirX*planeLength
+	return out
+}
+
+func (wv *wolfView) setLighting(colormap *Colormap, lightDistance float64) {
+	wv.colormap = colormap
+	wv.lightDistance = lightDistance
+	if lightDistance == 0 {
+		wv.lightDistance = WolfDefaultLightDistance
+	}
+}
+
+func (wv wolfView) shade(color types.PaletteIndex, darkness uint8, distance float64) types.PaletteIndex {
+	if wv.colormap == nil {
+		return color
+	}
+	return wv.colormap.Shade(color, wv.colormap.LightLevel(darkness, distance, wv.lightDistance))
+}
+
+func (wv wolfView) rayDir(column uint32) (float64, float64) {
+	var cameraX = 2*(float64(column)+0.5)/float64(wv.size.X) - 1
+	return wv.dirX + wv.planeX*cameraX, wv.dirY + wv.planeY*cameraX
+}
+
+func (wv wolfView) drawWall(rr interfaces.RawRenderer, world types.WorldWolf, column uint32, hit WolfHit) {
+	var darkness = world.AddDarkness(hit.Object.Darkness)
+	// A ray starting on a tile boundary next to a wall hits it at distance 0.
+	var distance = max(hit.Distance, WolfNearClip)
+	var lineHeight = float64(wv.size.Y) * wv.wallHeight / distance
+	var wallTop = float64(wv.size.Y)/2 - lineHeight/2
+	var top = max(int(math.Ceil(wallTop)), 0)
+	var bottom = min(int(math.Floor(float64(wv.size.Y)/2+lineHeight/2)), int(wv.size.Y)-1)
+	var sampler = hit.Object.GetSampler(hit.Side)
+	if sampler == nil {
+		for y := top; y <= bottom; y++ {
+			rr.DrawBackPixel(column, uint32(y), wv.shade(hit.Object.Color, darkness, distance))
+		}
+		return
+	}
+	var point = types.SamplerPoint{X: hit.TextureX()}
+	for y := top; y <= bottom; y++ {
+		point.Y = min(max((float64(y)+0.5-wallTop)/lineHeight, 0), 1)
+		rr.DrawBackPixel(column, uint32(y), wv.shade(sampler.GetAtPoint(point), darkness, distance))
+	}
+}
+
+// drawSurfaces casts the floor and ceiling row by row; walls are drawn over them afterwards.
+func (wv wolfView) drawSurfaces(rr interfaces.RawRenderer, world types.WorldWolf) {
+	if !world.Floor.Enabled && !world.Ceiling.Enabled {
+		return
+	}
+	var leftX, leftY = wv.dirX - wv.planeX, wv.dirY - wv.planeY
+	var rightX, rightY = wv.dirX + wv.planeX, wv.dirY + wv.planeY
+	var horizon = float64(wv.size.Y) / 2
+	// Start at the first row whose centre is below the horizon; on an odd
+	// height the middle row sits on it and has no finite distance.
+	for y := (wv.size.Y + 1) / 2; y < wv.size.Y; y++ {
+		var rowDistance = horizon * wv.wallHeight / (float64(y) + 0.5 - horizon)
+		var stepX = rowDistance * (rightX - leftX) / float64(wv.size.X)
+		var stepY = rowDistance * (rightY - leftY) / float64(wv.size.X)
+		var floorX = wv.pos.X + rowDistance*leftX + stepX/2
+		var floorY = wv.pos.Y + rowDistance*leftY + stepY/2
+		var ceilingRow = wv.size.Y - 1 - y
+		for x := uint32(0); x < wv.size.X; x++ {
+			if world.Floor.Enabled {
+				rr.DrawBackPixel(x, y, wv.shade(world.Floor.ColorAt(floorX, floorY), world.Darkness, rowDistance))
+			}
+			if world.Ceiling.Enabled {
+				rr.DrawBackPixel(x, ceilingRow, wv.shade(world.Ceiling.ColorAt(floorX, floorY), world.Darkness, rowDistance))
+			}
+			floorX += stepX
+			floorY += stepY
+		}
+	}
+}
+
+// drawSprites projects the world's sprites and draws them back to front,
+// skipping every column where the wall pass left something closer in hits.
+func (wv wolfView) drawSprites(rr interfaces.RawRenderer, world types.WorldWolf, hits []WolfHit) {
+	type projected struct {
+		sprite *types.SpriteWolf
+		x      float64
+		depth  float64
+	}
+	var invDet = 1 / (wv.planeX*wv.dirY - wv.dirX*wv.planeY)
+	var sprites = make([]projected, 0, len(world.Sprites))
+	for _, sprite := range world.Sprites {
+		if sprite.Sampler == nil {
+			continue
+		}
+		var relX, relY = sprite.Position.X - wv.pos.X, sprite.Position.Y - wv.pos.Y
+		var depth = invDet * (-wv.planeY*relX + wv.planeX*relY)
+		if depth <= 0 {
+			continue
+		}
+		sprites = append(sprites, projected{sprite: sprite, x: invDet * (wv.dirY*relX - wv.dirX*relY), depth: depth})
+	}
+	slices.SortFunc(sprites, func(a projected, b projected) int {
+		if c := cmp.Compare(b.depth, a.depth); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.sprite.ID, b.sprite.ID)
+	})
+
+	var planeLength = math.Hypot(wv.planeX, wv.planeY)
+	var horizon = float64(wv.size.Y) / 2
+	for _, p := range sprites {
+		var width, height = p.sprite.Width, p.sprite.Height
+		if width == 0 {
+			width = 1
+		}
+		if height == 0 {
+			height = wv.wallHeight
+		}
+		var centerX = float64(wv.size.X) / 2 * (1 + p.x/p.depth)
+		var screenWidth = width * float64(wv.size.X) / (2 * planeLength * p.depth)
+		var screenHeight = height * float64(wv.size.Y) / p.depth
+		var darkness = world.AddDarkness(p.sprite.Darkness)
+		var left = centerX - screenWidth/2
+		var bottom = horizon + float64(wv.size.Y)*wv.wallHeight/(2*p.depth)
+		var top = bottom - screenHeight
+
+		var startX = max(int(math.Ceil(left-0.5)), 0)
+		var endX = min(int(math.Ceil(left+screenWidth-0.5)), int(wv.size.X))
+		var startY = max(int(math.Ceil(top-0.5)), 0)
+		var endY = min(int(math.Ceil(bottom-0.5)), int(wv.size.Y))
+		for x := startX; x < endX; x++ {
+			if x < len(hits) && hits[x].Object != nil && hits[x].Distance <= p.depth {
+				continue
+			}
+			var point = types.SamplerPoint{X: min(max((float64(x)+0.5-left)/screenWidth, 0), 1)}
+			for y := startY; y < endY; y++ {
+				point.Y = min(max((float64(y)+0.5-top)/screenHeight, 0), 1)
+				var color = p.sprite.Sampler.GetAtPoint(point)
+				if p.sprite.HasTransparency && color == p.sprite.Transparent {
+					continue
+				}
+				rr.DrawBackPixel(uint32(x), uint32(y), wv.shade(color, darkness, p.depth))
+			}
+		}
+	}
+}
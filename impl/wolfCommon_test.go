@@ -0,0 +1,45 @@
+package impl
+
+import (
+	"testing"
+
+	"github.com/averseabfun/flux/interfaces"
+	"github.com/averseabfun/flux/types"
+)
+
+func TestWolfSurfacesOddHeight(t *testing.T) {
+	const untouched types.PaletteIndex = 99
+	var image = &IndexedImage{Width: 2, Height: 2, Pixels: []types.PaletteIndex{1, 2, 3, 4}}
+	var world = newWorldWolf()
+	world.Floor = types.WolfSurface{Enabled: true, Sampler: NewImageSampler(image)}
+	world.Ceiling = types.WolfSurface{Enabled: true, Color: 5}
+
+	for name, renderer := range map[string]interfaces.WolfRenderer{
+		"dda":     &WolfDDARenderer{},
+		"marcher": &WolfRayMarcher{},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var rr = &Headless{}
+			if err := rr.InitRenderer("test", 8, 7); err != nil {
+				t.Fatal(err)
+			}
+			rr.FillBack(untouched)
+			renderer.SetParent(rr)
+			renderer.RenderWorld(*world, types.Point{X: 3, Y: 3}, 0)
+
+			for y := uint32(0); y < 7; y++ {
+				for x := uint32(0); x < 8; x++ {
+					var got, _ = rr.GetBackPixel(x, y)
+					switch {
+					case y == 3 && got != untouched:
+						t.Errorf("horizon pixel (%d, %d) = %d, want it left alone", x, y, got)
+					case y < 3 && got != 5:
+						t.Errorf("ceiling pixel (%d, %d) = %d, want 5", x, y, got)
+					case y > 3 && (got < 1 || got > 4):
+						t.Errorf("floor pixel (%d, %d) = %d, want a texel of the floor image", x, y, got)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package impl
+
+import (
+	"math"
+	"slices"
+
+	"github.com/averseabfun/flux/interfaces"
+	"github.com/averseabfun/flux/types"
+)
+
+var WolfDDADefaultFOV types.Degree = 66
+var WolfDDADefaultWallHeight float64 = 8
+var WolfDDAMaxSteps int = 1024
+var WolfDefaultLightDistance float64 = 64
+
+// WolfDDAMaxGridSize bounds the tile grid on each axis. Wall tiles at or past
+// it are left out of the grid, so the renderer never sees them.
+var WolfDDAMaxGridSize uint32 = 1024
+
+// WolfDDARenderer rasterizes the world's rectangles into a grid of unit tiles
+// and casts one ray per screen column through it with a DDA.
+type WolfDDARenderer struct {
+	rr         interfaces.RawRenderer
+	FOV        types.Degree
+	WallHeight float64
+	// Colormap shades the world by distance and light values when set,
+	// reaching the darkest level at LightDistance (or WolfDefaultLightDistance).
+	Colormap      *Colormap
+	LightDistance float64
+
+	grid       []*types.RectWolf
+	gridWidth  int
+	gridHeight int
+	gridWalls  map[types.ObjectID]gridWall
+	hits       []WolfHit
+}
+
+// gridWall is what the grid was built from for one wall, so that buildGrid
+// can tell whether anything moved since.
+type gridWall struct {
+	object *types.RectWolf
+	start  types.Point
+	end    types.Point
+}
+
+func (wdr WolfDDARenderer) Parent() interfaces.RawRenderer {
+	return wdr.rr
+}
+
+func (wdr *WolfDDARenderer) SetParent(rr interfaces.RawRenderer) {
+	wdr.rr = rr
+}
+
+func (wdr WolfDDARenderer) CanUseCurrentRawRenderer() bool {
+	return true
+}
+
+// LastHits returns the hit for every screen column of the last rendered frame.
+func (wdr *WolfDDARenderer) LastHits() []WolfHit {
+	return wdr.hits
+}
+
+func (wdr *WolfDDARenderer) gridIsCurrent(world types.WorldWolf) bool {
+	if wdr.gridWalls == nil || len(wdr.gridWalls) != len(world.Objects) {
+		return false
+	}
+	for id, object := range world.Objects {
+		if wdr.gridWalls[id] != (gridWall{object: object, start: object.Start, end: object.End}) {
+			return false
+		}
+	}
+	return true
+}
+
+// buildGrid rasterizes the world's walls into tiles, reusing the last grid if
+// no wall was added, removed or moved since it was built.
+func (wdr *WolfDDARenderer) buildGrid(world types.WorldWolf) {
+	if wdr.gridIsCurrent(world) {
+		return
+	}
+	wdr.gridWalls = make(map[types.ObjectID]gridWall, len(world.Objects))
+	wdr.gridWidth, wdr.gridHeight = 0, 0
+	for id, object := range world.Objects {
+		wdr.gridWalls[id] = gridWall{object: object, start: object.Start, end: object.End}
+		if object.Start.X < WolfDDAMaxGridSize && object.Start.Y < WolfDDAMaxGridSize {
+			wdr.gridWidth = max(wdr.gridWidth, int(min(object.End.X, WolfDDAMaxGridSize-1))+1)
+			wdr.gridHeight = max(wdr.gridHeight, int(min(object.End.Y, WolfDDAMaxGridSize-1))+1)
+		}
+	}
+	var size = wdr.gridWidth * wdr.gridHeight
+	if cap(wdr.grid) < size {
+		wdr.grid = make([]*types.RectWolf, size)
+	}
+	wdr.grid = wdr.grid[:size]
+	clear(wdr.grid)
+
+	var ids = make([]types.ObjectID, 0, len(world.Objects))
+	for id := range world.Objects {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	for _, id := range ids {
+		var object = world.Objects[id]
+		for y := int(object.Start.Y); y <= int(object.End.Y) && y < wdr.gridHeight; y++ {
+			for x := int(object.Start.X); x <= int(object.End.X) && x < wdr.gridWidth; x++ {
+				wdr.grid[y*wdr.gridWidth+x] = object
+			}
+		}
+	}
+}
+
+func (wdr *WolfDDARenderer) tileAt(x int, y int) *types.RectWolf {
+	if x < 0 || y < 0 || x >= wdr.gridWidth || y >= wdr.gridHeight {
+		return nil
+	}
+	return wdr.grid[y*wdr.gridWidth+x]
+}
+
+func (wdr *WolfDDARenderer) castRay(pos types.SamplerPoint, rayX float64, rayY float64) WolfHit {
+	var mapX, mapY = int(math.Floor(pos.X)), int(math.Floor(pos.Y))
+	var deltaX, deltaY = math.Abs(1 / rayX), math.Abs(1 / rayY)
+	var stepX, stepY = 1, 1
+	var sideDistX, sideDistY float64
+	if rayX < 0 {
+		stepX = -1
+		sideDistX = (pos.X - float64(mapX)) * deltaX
+	} else {
+		sideDistX = (float64(mapX) + 1 - pos.X) * deltaX
+	}
+	if rayY < 0 {
+		stepY = -1
+		sideDistY = (pos.Y - float64(mapY)) * deltaY
+	} else {
+		sideDistY = (float64(mapY) + 1 - pos.Y) * deltaY
+	}
+
+	for i := 0; i < WolfDDAMaxSteps; i++ {
+		var hit WolfHit
+		if sideDistX < sideDistY {
+			hit.Distance = sideDistX
+			sideDistX += deltaX
+			mapX += stepX
+			hit.Side = types.SideLeft
+			if stepX < 0 {
+				hit.Side = types.SideRight
+			}
+		} else {
+			hit.Distance = sideDistY
+			sideDistY += deltaY
+			mapY += stepY
+			hit.Side = types.SideTop
+			if stepY < 0 {
+				hit.Side = types.SideBottom
+			}
+		}
+		if (stepX > 0 && mapX >= wdr.gridWidth) || (stepX < 0 && mapX < 0) ||
+			(stepY > 0 && mapY >= wdr.gridHeight) || (stepY < 0 && mapY < 0) {
+			break
+		}
+		if hit.Object = wdr.tileAt(mapX, mapY); hit.Object != nil {
+			hit.RayDistance = hit.Distance * math.Hypot(rayX, rayY)
+			hit.At = types.SamplerPoint{X: pos.X + hit.Distance*rayX, Y: pos.Y + hit.Distance*rayY}
+			return hit
+		}
+	}
+	return WolfHit{Distance: math.Inf(1), RayDistance: math.Inf(1)}
+}
+
+func (wdr *WolfDDARenderer) RenderWorld(world types.WorldWolf, cameraPos types.Point, cameraRotation types.Degree) {
+	wdr.RenderWorldPrecise(world, types.SamplerPoint{X: float64(cameraPos.X), Y: float64(cameraPos.Y)}, cameraRotation)
+}
+
+func (wdr *WolfDDARenderer) RenderWorldPrecise(world types.WorldWolf, cameraPos types.SamplerPoint, cameraRotation types.Degree) {
+	var fov = wdr.FOV
+	if fov == 0 {
+		fov = WolfDDADefaultFOV
+	}
+	var wallHeight = wdr.WallHeight
+	if wallHeight == 0 {
+		wallHeight = WolfDDADefaultWallHeight
+	}
+	var size = wdr.rr.GetSize()
+	var view = newWolfView(cameraPos, cameraRotation, fov, size, wallHeight)
+
+	view.setLighting(wdr.Colormap, wdr.LightDistance)
+	wdr.buildGrid(world)
+	view.drawSurfaces(wdr.rr, world)
+	wdr.hits = slices.Grow(wdr.hits[:0], int(size.X))[:size.X]
+	for column := uint32(0); column < size.X; column++ {
+		var rayX, rayY = view.rayDir(column)
+		var hit = wdr.castRay(view.pos, rayX, rayY)
+		wdr.hits[column] = hit
+		if hit.Object != nil {
+			view.drawWall(wdr.rr, world, column, hit)
+		}
+	}
+	view.drawSprites(wdr.rr, world, wdr.hits)
+}
@@ -0,0 +1,170 @@
+package impl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/averseabfun/flux/types"
+)
+
+var ErrUnserializableSampler = errors.New("sampler cannot be written to a map")
+
+func FormatWolfSampler(sampler types.Sampler) (string, error) {
+	switch sampler := sampler.(type) {
+	case *FlatSampler:
+		return "flat:" + strconv.Itoa(int(sampler.GetColor())), nil
+	case *GradientSampler:
+		var out = "gradient"
+		for _, color := range sampler.GetGradient().Colors {
+			out += ":" + strconv.Itoa(int(color))
+		}
+		return out, nil
+	default:
+		return "", fmt.Errorf("%w: %T", ErrUnserializableSampler, sampler)
+	}
+}
+
+func formatMapFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func sortedIDs[T any](objects map[types.ObjectID]T) []types.ObjectID {
+	var out = make([]types.ObjectID, 0, len(objects))
+	for id := range objects {
+		out = append(out, id)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// ExportWolfWorld writes world to path in the FLUXMAP format.
+func ExportWolfWorld(path string, world types.WorldWolf) error {
+	var file, err = os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteWolfMap(file, world); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// WriteWolfMap writes world to w in the FLUXMAP format, in a stable order so
+// that saving an unchanged world gives the same bytes.
+func WriteWolfMap(w io.Writer, world types.WorldWolf) error {
+	var bw = bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%s %d\n", wolfMapMagic, WolfMapVersion)
+
+	fmt.Fprintf(bw, "\n[world]\n")
+	fmt.Fprintf(bw, "darkness = %d\n", world.Darkness)
+	for _, surface := range []struct {
+		name    string
+		surface types.WolfSurface
+	}{{"floor", world.Floor}, {"ceiling", world.Ceiling}} {
+		if !surface.surface.Enabled {
+			continue
+		}
+		fmt.Fprintf(bw, "%s = %d\n", surface.name, surface.surface.Color)
+		if surface.surface.Sampler != nil {
+			var spec, err = FormatWolfSampler(surface.surface.Sampler)
+			if err != nil {
+				return fmt.Errorf("%s: %w", surface.name, err)
+			}
+			fmt.Fprintf(bw, "%s_sampler = %s\n", surface.name, spec)
+		}
+		if surface.surface.Scale != 0 {
+			fmt.Fprintf(bw, "%s_scale = %s\n", surface.name, formatMapFloat(surface.surface.Scale))
+		}
+	}
+
+	if len(world.Palette) > 0 {
+		fmt.Fprintf(bw, "\n[palette]\n")
+		var indices = make([]types.PaletteIndex, 0, len(world.Palette))
+		for index := range world.Palette {
+			indices = append(indices, index)
+		}
+		slices.Sort(indices)
+		for _, index := range indices {
+			var color = world.Palette[index]
+			fmt.Fprintf(bw, "%d = %d, %d, %d\n", index, color.R, color.G, color.B)
+		}
+	}
+
+	fmt.Fprintf(bw, "\n[player]\n")
+	fmt.Fprintf(bw, "x = %s\ny = %s\nheading = %s\n", formatMapFloat(world.PlayerStart.X), formatMapFloat(world.PlayerStart.Y), formatMapFloat(float64(world.PlayerHeading)))
+
+	fmt.Fprintf(bw, "\n[walls]\n")
+	for _, id := range sortedIDs(world.Objects) {
+		var object = world.Objects[id]
+		var fields = []string{
+			strconv.FormatUint(uint64(object.ID), 10), strconv.Itoa(int(object.Color)),
+			strconv.FormatUint(uint64(object.Start.X), 10), strconv.FormatUint(uint64(object.Start.Y), 10),
+			strconv.FormatUint(uint64(object.End.X), 10), strconv.FormatUint(uint64(object.End.Y), 10),
+		}
+		if object.Sampler != nil {
+			var spec, err = FormatWolfSampler(object.Sampler)
+			if err != nil {
+				return fmt.Errorf("wall %d: %w", id, err)
+			}
+			fields = append(fields, "sampler="+spec)
+		}
+		for _, name := range []string{"top", "left", "right", "bottom"} {
+			var sampler = object.SideSamplers[wolfSideNames[name]]
+			if sampler == nil {
+				continue
+			}
+			var spec, err = FormatWolfSampler(sampler)
+			if err != nil {
+				return fmt.Errorf("wall %d %s side: %w", id, name, err)
+			}
+			fields = append(fields, name+"="+spec)
+		}
+		if object.Darkness != 0 {
+			fields = append(fields, "darkness="+strconv.Itoa(int(object.Darkness)))
+		}
+		fmt.Fprintln(bw, strings.Join(fields, ", "))
+	}
+
+	if len(world.Sprites) > 0 {
+		fmt.Fprintf(bw, "\n[sprites]\n")
+	}
+	for _, id := range sortedIDs(world.Sprites) {
+		var sprite = world.Sprites[id]
+		if sprite.Sampler == nil {
+			return fmt.Errorf("sprite %d: has no sampler", id)
+		}
+		var color = "0"
+		var options []string
+		if flat, ok := sprite.Sampler.(*FlatSampler); ok {
+			color = strconv.Itoa(int(flat.GetColor()))
+		} else {
+			var spec, err = FormatWolfSampler(sprite.Sampler)
+			if err != nil {
+				return fmt.Errorf("sprite %d: %w", id, err)
+			}
+			options = append(options, "sampler="+spec)
+		}
+		if sprite.Width != 0 {
+			options = append(options, "width="+formatMapFloat(sprite.Width))
+		}
+		if sprite.Height != 0 {
+			options = append(options, "height="+formatMapFloat(sprite.Height))
+		}
+		if sprite.HasTransparency {
+			options = append(options, "transparent="+strconv.Itoa(int(sprite.Transparent)))
+		}
+		if sprite.Darkness != 0 {
+			options = append(options, "darkness="+strconv.Itoa(int(sprite.Darkness)))
+		}
+		var fields = append([]string{strconv.FormatUint(uint64(sprite.ID), 10), color, formatMapFloat(sprite.Position.X), formatMapFloat(sprite.Position.Y)}, options...)
+		fmt.Fprintln(bw, strings.Join(fields, ", "))
+	}
+	return bw.Flush()
+}
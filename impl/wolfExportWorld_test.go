@@ -0,0 +1,116 @@
+package impl
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/averseabfun/flux/types"
+)
+
+func flatSampler(color types.PaletteIndex) *FlatSampler {
+	var out = &FlatSampler{}
+	out.SetColor(color)
+	return out
+}
+
+func gradientSampler(colors ...types.PaletteIndex) *GradientSampler {
+	var out = &GradientSampler{}
+	out.SetGradient(types.Gradient{Colors: colors})
+	return out
+}
+
+func roundTripWorlds() map[string]*types.WorldWolf {
+	var empty = newWorldWolf()
+
+	var full = newWorldWolf()
+	full.Darkness = 12
+	full.Floor = types.WolfSurface{Enabled: true, Color: 3, Sampler: gradientSampler(3, 4, 5), Scale: 2.5}
+	full.Ceiling = types.WolfSurface{Enabled: true, Color: 7}
+	full.PlayerStart = types.SamplerPoint{X: 1.5, Y: 2.25}
+	full.PlayerHeading = 137.5
+	full.Palette = map[types.PaletteIndex]types.Color{
+		0:   {R: 0, G: 0, B: 0},
+		1:   {R: 63, G: 0, B: 0},
+		255: {R: 12, G: 34, B: 56},
+	}
+	for _, wall := range []*types.RectWolf{
+		{ID: 1, Color: 1, Start: types.Point{X: 0, Y: 0}, End: types.Point{X: 4, Y: 0}},
+		{ID: 2, Color: 2, Start: types.Point{X: 0, Y: 1}, End: types.Point{X: 0, Y: 4}, Sampler: flatSampler(9), Darkness: 40},
+		{
+			ID: 10, Color: 255, Start: types.Point{X: 2, Y: 2}, End: types.Point{X: 3, Y: 5},
+			Sampler: gradientSampler(1, 2),
+			SideSamplers: map[types.Side]types.Sampler{
+				types.SideTop:    flatSampler(20),
+				types.SideLeft:   gradientSampler(21, 22, 23),
+				types.SideRight:  flatSampler(24),
+				types.SideBottom: flatSampler(25),
+			},
+			Darkness: 255,
+		},
+		{ID: 11, Color: 0, Start: types.Point{X: 7, Y: 7}, End: types.Point{X: 7, Y: 7}, SideSamplers: map[types.Side]types.Sampler{types.SideLeft: flatSampler(5)}},
+	} {
+		wall.World = full
+		full.Objects[wall.ID] = wall
+	}
+	for _, sprite := range []*types.SpriteWolf{
+		{ID: 1, Position: types.SamplerPoint{X: 1.5, Y: 1.5}, Sampler: flatSampler(30)},
+		{ID: 2, Position: types.SamplerPoint{X: 0.125, Y: 3}, Sampler: gradientSampler(31, 32), Width: 0.5, Height: 0.75, Transparent: 0, HasTransparency: true, Darkness: 8},
+		{ID: 5, Position: types.SamplerPoint{X: 3, Y: 0.1}, Sampler: flatSampler(0), Transparent: 200, HasTransparency: true},
+	} {
+		sprite.World = full
+		full.Sprites[sprite.ID] = sprite
+	}
+
+	var floorOnly = newWorldWolf()
+	floorOnly.Floor = types.WolfSurface{Enabled: true, Color: 0}
+	floorOnly.PlayerHeading = -90
+
+	return map[string]*types.WorldWolf{"empty": empty, "full": full, "floor only": floorOnly}
+}
+
+func TestWolfMapRoundTrip(t *testing.T) {
+	for name, world := range roundTripWorlds() {
+		var path = filepath.Join(t.TempDir(), "world.txt")
+		if err := ExportWolfWorld(path, *world); err != nil {
+			t.Fatalf("%s: export: %v", name, err)
+		}
+		var got, err = ImportWolfWorld(path)
+		if err != nil {
+			t.Fatalf("%s: import: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, *world) {
+			t.Errorf("%s: import(export(w)) differs:\ngot  %+v\nwant %+v", name, got, *world)
+		}
+	}
+}
+
+func TestWriteWolfMapStable(t *testing.T) {
+	for name, world := range roundTripWorlds() {
+		var first, second bytes.Buffer
+		if err := WriteWolfMap(&first, *world); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		var parsed, err = ParseWolfWorld(bytes.NewReader(first.Bytes()), name)
+		if err != nil {
+			t.Fatalf("%s: parse: %v", name, err)
+		}
+		if err := WriteWolfMap(&second, parsed); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !bytes.Equal(first.Bytes(), second.Bytes()) {
+			t.Errorf("%s: rewriting changed the map:\n%s\n---\n%s", name, first.String(), second.String())
+		}
+	}
+}
+
+func TestWriteWolfMapUnserializableSampler(t *testing.T) {
+	var world = newWorldWolf()
+	world.Objects[1] = &types.RectWolf{ID: 1, End: types.Point{X: 1}, Sampler: &PointSampler{}}
+	var err = WriteWolfMap(&bytes.Buffer{}, *world)
+	if !errors.Is(err, ErrUnserializableSampler) {
+		t.Errorf("got %v, want %v", err, ErrUnserializableSampler)
+	}
+}
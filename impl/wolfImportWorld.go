@@ -1,6 +1,10 @@
 package impl
 
 import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
@@ -8,40 +12,131 @@ import (
 	"github.com/averseabfun/flux/types"
 )
 
+// MapError is an error found while reading a map, pointing at where it happened.
+type MapError struct {
+	Path string
+	Line int
+	Err  error
+}
+
+func (me *MapError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", me.Path, me.Line, me.Err)
+}
+
+func (me *MapError) Unwrap() error {
+	return me.Err
+}
+
+func mapErrorf(path string, line int, format string, args ...any) error {
+	return &MapError{Path: path, Line: line, Err: fmt.Errorf(format, args...)}
+}
+
+func newWorldWolf() *types.WorldWolf {
+	return &types.WorldWolf{Objects: make(map[types.ObjectID]*types.RectWolf), Sprites: make(map[types.ObjectID]*types.SpriteWolf)}
+}
+
+// ImportWolfWorld loads a map in the FLUXMAP format, or in the older layout of
+// one "id,color,x1,y1,x2,y2" wall per line if the file has no FLUXMAP header.
 func ImportWolfWorld(path string) (types.WorldWolf, error) {
-	var byteData, err = os.ReadFile(path)
+	var data, err = os.ReadFile(path)
 	if err != nil {
 		return types.WorldWolf{}, err
 	}
-	var data = string(byteData)
-	var out = types.WorldWolf{Objects: make(map[types.ObjectID]*types.RectWolf)}
-	for _, d := range strings.Split(data, "\n") {
-		var d2 = strings.Split(d, ",")
-		objId, err := strconv.Atoi(d2[0])
-		if err != nil {
-			return out, err
+	return ParseWolfWorld(bytes.NewReader(data), path)
+}
+
+// ParseWolfWorld reads a map from r, using name in error messages.
+func ParseWolfWorld(r io.Reader, name string) (types.WorldWolf, error) {
+	var data, err = io.ReadAll(r)
+	if err != nil {
+		return types.WorldWolf{}, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		var trimmed = strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
 		}
-		clr, err := strconv.Atoi(d2[1])
-		if err != nil {
-			return out, err
+		if strings.HasPrefix(trimmed, wolfMapMagic) {
+			return ParseWolfMap(bytes.NewReader(data), name)
 		}
-		x1, err := strconv.Atoi(d2[2])
-		if err != nil {
-			return out, err
+		break
+	}
+	return ParseLegacyWolfWorld(bytes.NewReader(data), name)
+}
+
+// ParseLegacyWolfWorld reads the older CSV layout of one
+// "id,color,x1,y1,x2,y2" wall per line. Blank lines are skipped.
+func ParseLegacyWolfWorld(r io.Reader, name string) (types.WorldWolf, error) {
+	var out = newWorldWolf()
+	var scanner = bufio.NewScanner(r)
+	var lineNumber = 0
+	for scanner.Scan() {
+		lineNumber++
+		var line = strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
 		}
-		y1, err := strconv.Atoi(d2[3])
-		if err != nil {
-			return out, err
+		var fields = strings.Split(line, ",")
+		if len(fields) != 6 {
+			return *out, mapErrorf(name, lineNumber, "expected 6 comma-separated fields, got %d", len(fields))
 		}
-		x2, err := strconv.Atoi(d2[4])
+		var object, err = parseWallFields(fields)
 		if err != nil {
-			return out, err
+			return *out, &MapError{Path: name, Line: lineNumber, Err: err}
+		}
+		if err := addWall(out, object); err != nil {
+			return *out, &MapError{Path: name, Line: lineNumber, Err: err}
 		}
-		y2, err := strconv.Atoi(d2[5])
+	}
+	if err := scanner.Err(); err != nil {
+		return *out, err
+	}
+	return *out, nil
+}
+
+func parseUint(field string, what string, bits int) (uint64, error) {
+	var value, err = strconv.ParseUint(strings.TrimSpace(field), 10, bits)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", what, strings.TrimSpace(field))
+	}
+	return value, nil
+}
+
+func parseFloat(field string, what string) (float64, error) {
+	var value, err = strconv.ParseFloat(strings.TrimSpace(field), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", what, strings.TrimSpace(field))
+	}
+	return value, nil
+}
+
+func parseWallFields(fields []string) (*types.RectWolf, error) {
+	var names = []string{"id", "color", "x1", "y1", "x2", "y2"}
+	var bits = []int{64, 8, 32, 32, 32, 32}
+	var values [6]uint64
+	for i := range values {
+		var value, err = parseUint(fields[i], names[i], bits[i])
 		if err != nil {
-			return out, err
+			return nil, err
 		}
-		out.Objects[types.ObjectID(objId)] = &types.RectWolf{Start: types.Point{X: uint32(x1), Y: uint32(y1)}, End: types.Point{X: uint32(x2), Y: uint32(y2)}, Color: types.PaletteIndex(clr), ID: types.ObjectID(objId), World: &out}
+		values[i] = value
+	}
+	if values[4] < values[2] || values[5] < values[3] {
+		return nil, fmt.Errorf("wall %d ends before it starts", values[0])
+	}
+	return &types.RectWolf{
+		ID:    types.ObjectID(values[0]),
+		Color: types.PaletteIndex(values[1]),
+		Start: types.Point{X: uint32(values[2]), Y: uint32(values[3])},
+		End:   types.Point{X: uint32(values[4]), Y: uint32(values[5])},
+	}, nil
+}
+
+func addWall(world *types.WorldWolf, object *types.RectWolf) error {
+	if _, ok := world.Objects[object.ID]; ok {
+		return fmt.Errorf("duplicate wall id %d", object.ID)
 	}
-	return out, nil
-}
\ No newline at end of file
+	object.World = world
+	world.Objects[object.ID] = object
+	return nil
+}
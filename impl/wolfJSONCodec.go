@@ -0,0 +1,248 @@
+package impl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/averseabfun/flux/types"
+)
+
+type wolfJSONSurface struct {
+	Color   types.PaletteIndex `json:"color"`
+	Sampler string             `json:"sampler,omitempty"`
+	Scale   float64            `json:"scale,omitempty"`
+}
+
+type wolfJSONPaletteEntry struct {
+	Index types.PaletteIndex `json:"index"`
+	R     uint8              `json:"r"`
+	G     uint8              `json:"g"`
+	B     uint8              `json:"b"`
+}
+
+type wolfJSONPlayer struct {
+	X       float64      `json:"x"`
+	Y       float64      `json:"y"`
+	Heading types.Degree `json:"heading"`
+}
+
+type wolfJSONWall struct {
+	ID       types.ObjectID     `json:"id"`
+	Color    types.PaletteIndex `json:"color"`
+	Start    [2]uint32          `json:"start"`
+	End      [2]uint32          `json:"end"`
+	Sampler  string             `json:"sampler,omitempty"`
+	Sides    map[string]string  `json:"sides,omitempty"`
+	Darkness uint8              `json:"darkness,omitempty"`
+}
+
+type wolfJSONSprite struct {
+	ID          types.ObjectID      `json:"id"`
+	Position    [2]float64          `json:"position"`
+	Sampler     string              `json:"sampler"`
+	Width       float64             `json:"width,omitempty"`
+	Height      float64             `json:"height,omitempty"`
+	Transparent *types.PaletteIndex `json:"transparent,omitempty"`
+	Darkness    uint8               `json:"darkness,omitempty"`
+}
+
+type wolfJSONWorld struct {
+	Version  int                    `json:"version"`
+	Darkness uint8                  `json:"darkness,omitempty"`
+	Floor    *wolfJSONSurface       `json:"floor,omitempty"`
+	Ceiling  *wolfJSONSurface       `json:"ceiling,omitempty"`
+	Palette  []wolfJSONPaletteEntry `json:"palette,omitempty"`
+	Player   wolfJSONPlayer         `json:"player"`
+	Walls    []wolfJSONWall         `json:"walls"`
+	Sprites  []wolfJSONSprite       `json:"sprites,omitempty"`
+}
+
+type WolfJSONCodec struct{}
+
+func (wjc *WolfJSONCodec) Name() string {
+	return "json"
+}
+
+func (wjc *WolfJSONCodec) Extensions() []string {
+	return []string{".json"}
+}
+
+func (wjc *WolfJSONCodec) Magic() []byte {
+	return nil
+}
+
+func formatOptionalSampler(sampler types.Sampler) (string, error) {
+	if sampler == nil {
+		return "", nil
+	}
+	return FormatWolfSampler(sampler)
+}
+
+func parseOptionalSampler(spec string) (types.Sampler, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	return ParseWolfSampler(spec)
+}
+
+func encodeJSONSurface(surface types.WolfSurface) (*wolfJSONSurface, error) {
+	if !surface.Enabled {
+		return nil, nil
+	}
+	var spec, err = formatOptionalSampler(surface.Sampler)
+	return &wolfJSONSurface{Color: surface.Color, Sampler: spec, Scale: surface.Scale}, err
+}
+
+func decodeJSONSurface(surface *wolfJSONSurface) (types.WolfSurface, error) {
+	if surface == nil {
+		return types.WolfSurface{}, nil
+	}
+	var sampler, err = parseOptionalSampler(surface.Sampler)
+	return types.WolfSurface{Enabled: true, Color: surface.Color, Sampler: sampler, Scale: surface.Scale}, err
+}
+
+func (wjc *WolfJSONCodec) Encode(w io.Writer, world types.WorldWolf) error {
+	var out = wolfJSONWorld{
+		Version:  WolfMapVersion,
+		Darkness: world.Darkness,
+		Player:   wolfJSONPlayer{X: world.PlayerStart.X, Y: world.PlayerStart.Y, Heading: world.PlayerHeading},
+		Walls:    []wolfJSONWall{},
+	}
+	var err error
+	if out.Floor, err = encodeJSONSurface(world.Floor); err != nil {
+		return fmt.Errorf("floor: %w", err)
+	}
+	if out.Ceiling, err = encodeJSONSurface(world.Ceiling); err != nil {
+		return fmt.Errorf("ceiling: %w", err)
+	}
+	for index, color := range world.Palette {
+		out.Palette = append(out.Palette, wolfJSONPaletteEntry{Index: index, R: uint8(color.R), G: uint8(color.G), B: uint8(color.B)})
+	}
+	slices.SortFunc(out.Palette, func(a wolfJSONPaletteEntry, b wolfJSONPaletteEntry) int {
+		return int(a.Index) - int(b.Index)
+	})
+	for _, id := range sortedIDs(world.Objects) {
+		var object = world.Objects[id]
+		var wall = wolfJSONWall{
+			ID: object.ID, Color: object.Color, Darkness: object.Darkness,
+			Start: [2]uint32{object.Start.X, object.Start.Y}, End: [2]uint32{object.End.X, object.End.Y},
+		}
+		if wall.Sampler, err = formatOptionalSampler(object.Sampler); err != nil {
+			return fmt.Errorf("wall %d: %w", id, err)
+		}
+		for name, side := range wolfSideNames {
+			var sampler = object.SideSamplers[side]
+			if sampler == nil {
+				continue
+			}
+			if wall.Sides == nil {
+				wall.Sides = make(map[string]string)
+			}
+			if wall.Sides[name], err = FormatWolfSampler(sampler); err != nil {
+				return fmt.Errorf("wall %d %s side: %w", id, name, err)
+			}
+		}
+		out.Walls = append(out.Walls, wall)
+	}
+	for _, id := range sortedIDs(world.Sprites) {
+		var sprite = world.Sprites[id]
+		var encoded = wolfJSONSprite{
+			ID: sprite.ID, Position: [2]float64{sprite.Position.X, sprite.Position.Y},
+			Width: sprite.Width, Height: sprite.Height, Darkness: sprite.Darkness,
+		}
+		if sprite.Sampler == nil {
+			return fmt.Errorf("sprite %d: has no sampler", id)
+		}
+		if encoded.Sampler, err = FormatWolfSampler(sprite.Sampler); err != nil {
+			return fmt.Errorf("sprite %d: %w", id, err)
+		}
+		if sprite.HasTransparency {
+			var transparent = sprite.Transparent
+			encoded.Transparent = &transparent
+		}
+		out.Sprites = append(out.Sprites, encoded)
+	}
+	var encoder = json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(out)
+}
+
+func (wjc *WolfJSONCodec) Decode(r io.Reader, name string) (types.WorldWolf, error) {
+	var in wolfJSONWorld
+	var out = newWorldWolf()
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return *out, fmt.Errorf("%s: %w", name, err)
+	}
+	if in.Version != WolfMapVersion {
+		return *out, fmt.Errorf("%s: %w %d (expected %d)", name, ErrUnsupportedMapVersion, in.Version, WolfMapVersion)
+	}
+	var err error
+	out.Darkness = in.Darkness
+	out.PlayerStart = types.SamplerPoint{X: in.Player.X, Y: in.Player.Y}
+	out.PlayerHeading = in.Player.Heading
+	if out.Floor, err = decodeJSONSurface(in.Floor); err != nil {
+		return *out, fmt.Errorf("%s: floor: %w", name, err)
+	}
+	if out.Ceiling, err = decodeJSONSurface(in.Ceiling); err != nil {
+		return *out, fmt.Errorf("%s: ceiling: %w", name, err)
+	}
+	for _, entry := range in.Palette {
+		var color, err = types.FromRGBUint8(entry.R, entry.G, entry.B)
+		if err != nil {
+			return *out, fmt.Errorf("%s: palette entry %d: %w", name, entry.Index, err)
+		}
+		if out.Palette == nil {
+			out.Palette = make(map[types.PaletteIndex]types.Color)
+		}
+		out.Palette[entry.Index] = color
+	}
+	for _, wall := range in.Walls {
+		var object = &types.RectWolf{
+			ID: wall.ID, Color: wall.Color, Darkness: wall.Darkness,
+			Start: types.Point{X: wall.Start[0], Y: wall.Start[1]}, End: types.Point{X: wall.End[0], Y: wall.End[1]},
+		}
+		if object.End.X < object.Start.X || object.End.Y < object.Start.Y {
+			return *out, fmt.Errorf("%s: wall %d ends before it starts", name, wall.ID)
+		}
+		if object.Sampler, err = parseOptionalSampler(wall.Sampler); err != nil {
+			return *out, fmt.Errorf("%s: wall %d: %w", name, wall.ID, err)
+		}
+		for sideName, spec := range wall.Sides {
+			var side, ok = wolfSideNames[sideName]
+			if !ok {
+				return *out, fmt.Errorf("%s: wall %d: unknown side %q", name, wall.ID, sideName)
+			}
+			var sampler, err = ParseWolfSampler(spec)
+			if err != nil {
+				return *out, fmt.Errorf("%s: wall %d %s side: %w", name, wall.ID, sideName, err)
+			}
+			if object.SideSamplers == nil {
+				object.SideSamplers = make(map[types.Side]types.Sampler)
+			}
+			object.SideSamplers[side] = sampler
+		}
+		if err := addWall(out, object); err != nil {
+			return *out, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	for _, encoded := range in.Sprites {
+		if _, ok := out.Sprites[encoded.ID]; ok {
+			return *out, fmt.Errorf("%s: duplicate sprite id %d", name, encoded.ID)
+		}
+		var sprite = &types.SpriteWolf{
+			ID: encoded.ID, Position: types.SamplerPoint{X: encoded.Position[0], Y: encoded.Position[1]},
+			Width: encoded.Width, Height: encoded.Height, Darkness: encoded.Darkness, World: out,
+		}
+		if sprite.Sampler, err = ParseWolfSampler(encoded.Sampler); err != nil {
+			return *out, fmt.Errorf("%s: sprite %d: %w", name, encoded.ID, err)
+		}
+		if encoded.Transparent != nil {
+			sprite.Transparent = *encoded.Transparent
+			sprite.HasTransparency = true
+		}
+		out.Sprites[sprite.ID] = sprite
+	}
+	return *out, nil
+}
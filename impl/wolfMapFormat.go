@@ -0,0 +1,380 @@
+package impl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/averseabfun/flux/types"
+)
+
+// A FLUXMAP file starts with a "FLUXMAP <version>" header and is split into
+// [world], [palette], [player], [walls] and [sprites] sections. Anything after
+// a '#' is a comment. For example:
+//
+//	FLUXMAP 1
+//	[world]
+//	floor = 3              # flat floor color; floor_sampler and floor_scale texture it
+//	ceiling = 4
+//	darkness = 16
+//	[palette]
+//	1 = 63, 0, 0           # index = r, g, b with 6-bit channels
+//	[player]
+//	x = 2.5
+//	y = 2.5
+//	heading = 270
+//	[walls]
+//	# id, color, x1, y1, x2, y2, then optional key=value pairs:
+//	# sampler, top, left, right, bottom (samplers) and darkness
+//	1, 1, 10, 10, 20, 20, left=flat:2, darkness=32
+//	[sprites]
+//	# id, color, x, y, then optional key=value pairs:
+//	# sampler, width, height, transparent and darkness
+//	1, 2, 5.5, 5.5, width=0.5, transparent=0
+//
+// Samplers are written as "flat:<index>" or "gradient:<index>:<index>:...".
+
+const wolfMapMagic = "FLUXMAP"
+const WolfMapVersion = 1
+
+var ErrUnsupportedMapVersion = errors.New("unsupported map version")
+
+func stripMapComment(line string) string {
+	if i := strings.IndexByte(line, '#'); i >= 0 {
+		line = line[:i]
+	}
+	return strings.TrimSpace(line)
+}
+
+func splitMapKeyValue(line string) (string, string, error) {
+	var key, value, ok = strings.Cut(line, "=")
+	if !ok {
+		return "", "", fmt.Errorf("expected key = value, got %q", line)
+	}
+	return strings.TrimSpace(key), strings.TrimSpace(value), nil
+}
+
+func ParseWolfSampler(spec string) (types.Sampler, error) {
+	var parts = strings.Split(strings.TrimSpace(spec), ":")
+	switch parts[0] {
+	case "flat":
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("flat sampler needs exactly one color, got %q", spec)
+		}
+		var color, err = parseUint(parts[1], "sampler color", 8)
+		if err != nil {
+			return nil, err
+		}
+		var out = &FlatSampler{}
+		out.SetColor(types.PaletteIndex(color))
+		return out, nil
+	case "gradient":
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("gradient sampler needs at least one color, got %q", spec)
+		}
+		var gradient types.Gradient
+		for _, part := range parts[1:] {
+			var color, err = parseUint(part, "sampler color", 8)
+			if err != nil {
+				return nil, err
+			}
+			gradient.Colors = append(gradient.Colors, types.PaletteIndex(color))
+		}
+		var out = &GradientSampler{}
+		out.SetGradient(gradient)
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unknown sampler %q", spec)
+	}
+}
+
+type wolfMapParser struct {
+	world   *types.WorldWolf
+	section string
+}
+
+// ParseWolfMap reads a map in the FLUXMAP format from r, using name in error messages.
+func ParseWolfMap(r io.Reader, name string) (types.WorldWolf, error) {
+	var parser = wolfMapParser{world: newWorldWolf()}
+	var scanner = bufio.NewScanner(r)
+	var lineNumber = 0
+	var sawHeader = false
+	for scanner.Scan() {
+		lineNumber++
+		var line = stripMapComment(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var err error
+		switch {
+		case !sawHeader:
+			err = parseWolfMapHeader(line)
+			sawHeader = true
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			err = parser.startSection(strings.TrimSpace(line[1 : len(line)-1]))
+		default:
+			err = parser.parseLine(line)
+		}
+		if err != nil {
+			return *parser.world, &MapError{Path: name, Line: lineNumber, Err: err}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return *parser.world, err
+	}
+	if !sawHeader {
+		return *parser.world, mapErrorf(name, lineNumber, "missing %s header", wolfMapMagic)
+	}
+	return *parser.world, nil
+}
+
+func parseWolfMapHeader(line string) error {
+	var fields = strings.Fields(line)
+	if len(fields) != 2 || fields[0] != wolfMapMagic {
+		return fmt.Errorf("expected %q header, got %q", wolfMapMagic+" <version>", line)
+	}
+	var version, err = strconv.Atoi(fields[1])
+	if err != nil {
+		return fmt.Errorf("invalid map version %q", fields[1])
+	}
+	if version != WolfMapVersion {
+		return fmt.Errorf("%w %d (expected %d)", ErrUnsupportedMapVersion, version, WolfMapVersion)
+	}
+	return nil
+}
+
+func (wmp *wolfMapParser) startSection(section string) error {
+	switch section {
+	case "world", "palette", "player", "walls", "sprites":
+		wmp.section = section
+		return nil
+	default:
+		return fmt.Errorf("unknown section [%s]", section)
+	}
+}
+
+func (wmp *wolfMapParser) parseLine(line string) error {
+	switch wmp.section {
+	case "world":
+		return wmp.parseWorldLine(line)
+	case "palette":
+		return wmp.parsePaletteLine(line)
+	case "player":
+		return wmp.parsePlayerLine(line)
+	case "walls":
+		return wmp.parseWallLine(line)
+	case "sprites":
+		return wmp.parseSpriteLine(line)
+	default:
+		return errors.New("data outside of any section")
+	}
+}
+
+func (wmp *wolfMapParser) parseWorldLine(line string) error {
+	var key, value, err = splitMapKeyValue(line)
+	if err != nil {
+		return err
+	}
+	var surface *types.WolfSurface
+	var surfaceKey string
+	if name, rest, ok := strings.Cut(key, "_"); ok && (name == "floor" || name == "ceiling") {
+		key, surfaceKey = name, rest
+	}
+	switch key {
+	case "floor":
+		surface = &wmp.world.Floor
+	case "ceiling":
+		surface = &wmp.world.Ceiling
+	case "darkness":
+		var darkness, err = parseUint(value, "darkness", 8)
+		wmp.world.Darkness = uint8(darkness)
+		return err
+	default:
+		return fmt.Errorf("unknown world key %q", key)
+	}
+	surface.Enabled = true
+	switch surfaceKey {
+	case "":
+		var color, err = parseUint(value, key+" color", 8)
+		surface.Color = types.PaletteIndex(color)
+		return err
+	case "sampler":
+		surface.Sampler, err = ParseWolfSampler(value)
+		return err
+	case "scale":
+		surface.Scale, err = parseFloat(value, key+" scale")
+		return err
+	default:
+		return fmt.Errorf("unknown world key %q", key+"_"+surfaceKey)
+	}
+}
+
+func (wmp *wolfMapParser) parsePaletteLine(line string) error {
+	var key, value, err = splitMapKeyValue(line)
+	if err != nil {
+		return err
+	}
+	index, err := parseUint(key, "palette index", 8)
+	if err != nil {
+		return err
+	}
+	var channels = strings.Split(value, ",")
+	if len(channels) != 3 {
+		return fmt.Errorf("palette entry %d needs r, g, b, got %q", index, value)
+	}
+	var rgb [3]uint64
+	for i, channel := range channels {
+		if rgb[i], err = parseUint(channel, "color channel", 8); err != nil {
+			return err
+		}
+	}
+	color, err := types.FromRGBUint8(uint8(rgb[0]), uint8(rgb[1]), uint8(rgb[2]))
+	if err != nil {
+		return fmt.Errorf("palette entry %d: %w (channels go up to %d)", index, err, types.MAX_UINT6)
+	}
+	if wmp.world.Palette == nil {
+		wmp.world.Palette = make(map[types.PaletteIndex]types.Color)
+	}
+	wmp.world.Palette[types.PaletteIndex(index)] = color
+	return nil
+}
+
+func (wmp *wolfMapParser) parsePlayerLine(line string) error {
+	var key, value, err = splitMapKeyValue(line)
+	if err != nil {
+		return err
+	}
+	switch key {
+	case "x":
+		wmp.world.PlayerStart.X, err = parseFloat(value, "player x")
+	case "y":
+		wmp.world.PlayerStart.Y, err = parseFloat(value, "player y")
+	case "heading":
+		var heading float64
+		heading, err = parseFloat(value, "player heading")
+		wmp.world.PlayerHeading = types.Degree(heading)
+	default:
+		err = fmt.Errorf("unknown player key %q", key)
+	}
+	return err
+}
+
+// splitMapRecord splits a wall or sprite line into its positional fields and
+// its trailing key=value options.
+func splitMapRecord(line string, positional int) ([]string, map[string]string, error) {
+	var fields = strings.Split(line, ",")
+	if len(fields) < positional {
+		return nil, nil, fmt.Errorf("expected at least %d comma-separated fields, got %d", positional, len(fields))
+	}
+	var options = make(map[string]string)
+	for _, field := range fields[positional:] {
+		var key, value, err = splitMapKeyValue(field)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, ok := options[key]; ok {
+			return nil, nil, fmt.Errorf("option %q given twice", key)
+		}
+		options[key] = value
+	}
+	return fields[:positional], options, nil
+}
+
+var wolfSideNames = map[string]types.Side{
+	"top":    types.SideTop,
+	"left":   types.SideLeft,
+	"right":  types.SideRight,
+	"bottom": types.SideBottom,
+}
+
+func (wmp *wolfMapParser) parseWallLine(line string) error {
+	var fields, options, err = splitMapRecord(line, 6)
+	if err != nil {
+		return err
+	}
+	object, err := parseWallFields(fields)
+	if err != nil {
+		return err
+	}
+	for key, value := range options {
+		if side, ok := wolfSideNames[key]; ok {
+			var sampler, err = ParseWolfSampler(value)
+			if err != nil {
+				return err
+			}
+			if object.SideSamplers == nil {
+				object.SideSamplers = make(map[types.Side]types.Sampler)
+			}
+			object.SideSamplers[side] = sampler
+			continue
+		}
+		switch key {
+		case "sampler":
+			object.Sampler, err = ParseWolfSampler(value)
+		case "darkness":
+			var darkness uint64
+			darkness, err = parseUint(value, "darkness", 8)
+			object.Darkness = uint8(darkness)
+		default:
+			err = fmt.Errorf("unknown wall option %q", key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return addWall(wmp.world, object)
+}
+
+func (wmp *wolfMapParser) parseSpriteLine(line string) error {
+	var fields, options, err = splitMapRecord(line, 4)
+	if err != nil {
+		return err
+	}
+	id, err := parseUint(fields[0], "id", 64)
+	if err != nil {
+		return err
+	}
+	if _, ok := wmp.world.Sprites[types.ObjectID(id)]; ok {
+		return fmt.Errorf("duplicate sprite id %d", id)
+	}
+	sampler, err := ParseWolfSampler("flat:" + strings.TrimSpace(fields[1]))
+	if err != nil {
+		return err
+	}
+	var sprite = &types.SpriteWolf{ID: types.ObjectID(id), Sampler: sampler, World: wmp.world}
+	if sprite.Position.X, err = parseFloat(fields[2], "sprite x"); err != nil {
+		return err
+	}
+	if sprite.Position.Y, err = parseFloat(fields[3], "sprite y"); err != nil {
+		return err
+	}
+	for key, value := range options {
+		switch key {
+		case "sampler":
+			sprite.Sampler, err = ParseWolfSampler(value)
+		case "width":
+			sprite.Width, err = parseFloat(value, "sprite width")
+		case "height":
+			sprite.Height, err = parseFloat(value, "sprite height")
+		case "transparent":
+			var transparent uint64
+			transparent, err = parseUint(value, "transparent color", 8)
+			sprite.Transparent = types.PaletteIndex(transparent)
+			sprite.HasTransparency = true
+		case "darkness":
+			var darkness uint64
+			darkness, err = parseUint(value, "darkness", 8)
+			sprite.Darkness = uint8(darkness)
+		default:
+			err = fmt.Errorf("unknown sprite option %q", key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	wmp.world.Sprites[sprite.ID] = sprite
+	return nil
+}
@@ -1,8 +1,8 @@
 package impl
 
 import (
-	"fmt"
 	"math"
+	"slices"
 
 	"github.com/averseabfun/flux/interfaces"
 	"github.com/averseabfun/flux/types"
@@ -11,9 +11,17 @@ import (
 var WolfRayMarcherMarchSize float64 = 2
 var WolfRayMarcherHeightMultiplier float64 = 3
 var WolfRayMarcherMaxDepth int = 200
+var WolfRayMarcherFOV types.Degree = 66
+
+// wolfRayMarcherWallScale converts WolfRayMarcherHeightMultiplier into a
+// wolfView wall height, so the default of 3 matches WolfDDADefaultWallHeight.
+const wolfRayMarcherWallScale = 8.0 / 3
 
 type WolfRayMarcher struct {
-	rr interfaces.RawRenderer
+	rr            interfaces.RawRenderer
+	hits          []WolfHit
+	Colormap      *Colormap
+	LightDistance float64
 }
 
 func (wrm WolfRayMarcher) Parent() interfaces.RawRenderer {
@@ -39,43 +47,79 @@ func (wrm WolfRayMarcher) checkPositionForCollisions(world types.WorldWolf, poin
 	return out
 }
 
-func (wrm WolfRayMarcher) RenderWorld(world types.WorldWolf, cameraPos types.Point, cameraRotation types.Degree) {
-	var cameraRotationRads = cameraRotation.ToRadians()
-	var xOffset = math.Cos(float64(cameraRotationRads)) * WolfRayMarcherMarchSize
-	var yOffset = math.Sin(float64(cameraRotationRads)) * WolfRayMarcherMarchSize
-	var floatPos = types.SamplerPoint{X: float64(cameraPos.X), Y: float64(cameraPos.Y)}
-	var depth float64 = 0
-	var stepPos = func() {
-		floatPos.X += xOffset
-		floatPos.Y += yOffset
-		depth += WolfRayMarcherMarchSize
+func (wrm *WolfRayMarcher) LastHits() []WolfHit {
+	return wrm.hits
+}
+
+func (wrm WolfRayMarcher) objectAt(world types.WorldWolf, point types.SamplerPoint) *types.RectWolf {
+	if point.X < 0 || point.Y < 0 || point.X >= math.MaxUint32 || point.Y >= math.MaxUint32 {
+		return nil
 	}
-	var whichLine uint32 = 0
-	for whichLine <= wrm.rr.GetSize().X {
-		floatPos = types.SamplerPoint{X: float64(cameraPos.X), Y: float64(cameraPos.Y)}
-		depth = 0
-		var i = 0
-		for len(wrm.checkPositionForCollisions(world, types.Point{X: uint32(floatPos.X), Y: uint32(floatPos.Y)})) == 0 && i <= WolfRayMarcherMaxDepth {
-			stepPos()
-			fmt.Println(depth)
-			i++
+	var collisions = wrm.checkPositionForCollisions(world, types.Point{X: uint32(point.X), Y: uint32(point.Y)})
+	if len(collisions) == 0 {
+		return nil
+	}
+	return world.Objects[slices.Max(collisions)]
+}
+
+func (wrm WolfRayMarcher) marchRay(world types.WorldWolf, view wolfView, rayX float64, rayY float64) WolfHit {
+	var length = math.Hypot(rayX, rayY)
+	var stepX, stepY = rayX / length * WolfRayMarcherMarchSize, rayY / length * WolfRayMarcherMarchSize
+	var prev, floatPos = view.pos, view.pos
+	for i := 0; i <= WolfRayMarcherMaxDepth; i++ {
+		prev = floatPos
+		floatPos.X += stepX
+		floatPos.Y += stepY
+		var object = wrm.objectAt(world, floatPos)
+		if object == nil {
+			continue
 		}
-		if i > WolfRayMarcherMaxDepth {
-			var yPos uint32 = 0
-			for yPos <= wrm.rr.GetSize().Y {
-				wrm.rr.DrawBackPixel(whichLine, yPos, 0)
+		// Narrow the hit down between the last free position and this one.
+		for j := 0; j < 16; j++ {
+			var mid = types.SamplerPoint{X: (prev.X + floatPos.X) / 2, Y: (prev.Y + floatPos.Y) / 2}
+			if wrm.objectAt(world, mid) == object {
+				floatPos = mid
+			} else {
+				prev = mid
 			}
-			continue
 		}
-		fmt.Println(depth)
-		i = 0
-		var color = world.Objects[wrm.checkPositionForCollisions(world, types.Point{X: uint32(floatPos.X), Y: uint32(floatPos.Y)})[0]].Color
-		depth *= WolfRayMarcherHeightMultiplier
-		depth = math.Round(depth / 2)
-		var uintDepth uint32 = uint32(depth)
-		var yPos uint32 = (wrm.rr.GetSize().Y / 2) - uintDepth
-		for yPos <= (wrm.rr.GetSize().Y/2)+uintDepth {
-			wrm.rr.DrawBackPixel(whichLine, yPos, color)
+		var hit = WolfHit{Object: object, At: floatPos, Side: types.SideBottom}
+		switch {
+		case prev.X < float64(object.Start.X):
+			hit.Side = types.SideLeft
+		case prev.X >= float64(object.End.X)+1:
+			hit.Side = types.SideRight
+		case prev.Y < float64(object.Start.Y):
+			hit.Side = types.SideTop
+		}
+		hit.Distance = (floatPos.X-view.pos.X)*view.dirX + (floatPos.Y-view.pos.Y)*view.dirY
+		hit.RayDistance = math.Hypot(floatPos.X-view.pos.X, floatPos.Y-view.pos.Y)
+		return hit
+	}
+	return WolfHit{Distance: math.Inf(1), RayDistance: math.Inf(1)}
+}
+
+func (wrm *WolfRayMarcher) RenderWorld(world types.WorldWolf, cameraPos types.Point, cameraRotation types.Degree) {
+	wrm.RenderWorldPrecise(world, types.SamplerPoint{X: float64(cameraPos.X), Y: float64(cameraPos.Y)}, cameraRotation)
+}
+
+func (wrm *WolfRayMarcher) RenderWorldPrecise(world types.WorldWolf, cameraPos types.SamplerPoint, cameraRotation types.Degree) {
+	var size = wrm.rr.GetSize()
+	var view = newWolfView(cameraPos, cameraRotation, WolfRayMarcherFOV, size, WolfRayMarcherHeightMultiplier*wolfRayMarcherWallScale)
+	view.setLighting(wrm.Colormap, wrm.LightDistance)
+	view.drawSurfaces(wrm.rr, world)
+	wrm.hits = slices.Grow(wrm.hits[:0], int(size.X))[:size.X]
+	for whichLine := uint32(0); whichLine < size.X; whichLine++ {
+		var rayX, rayY = view.rayDir(whichLine)
+		var hit = wrm.marchRay(world, view, rayX, rayY)
+		wrm.hits[whichLine] = hit
+		if hit.Object != nil {
+			view.drawWall(wrm.rr, world, whichLine, hit)
+		} else if !world.Floor.Enabled && !world.Ceiling.Enabled {
+			for yPos := uint32(0); yPos < size.Y; yPos++ {
+				wrm.rr.DrawBackPixel(whichLine, yPos, 0)
+			}
 		}
 	}
+	view.drawSprites(wrm.rr, world, wrm.hits)
 }
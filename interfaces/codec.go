@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"io"
+
+	"github.com/averseabfun/flux/types"
+)
+
+type WolfWorldCodec interface {
+	Name() string
+	Extensions() []string
+	// Magic is the prefix every encoded world starts with, or nil if the
+	// format can only be recognized by its extension.
+	Magic() []byte
+	Decode(r io.Reader, name string) (types.WorldWolf, error)
+	Encode(w io.Writer, world types.WorldWolf) error
+}
@@ -1,13 +1,11 @@
 package interfaces
 
-import "github.com/go-gl/glfw/v3.3/glfw"
-
 type KeyGrabber interface {
-	GrabKey(key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) (continueSearching bool)
+	GrabKey(key Key, scancode int, action Action, mods ModifierKey) (continueSearching bool)
 }
 
 type MouseGrabber interface {
-	GrabMouse(button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey, posX float64, posY float64) (continueSearching bool)
+	GrabMouse(button MouseButton, action Action, mods ModifierKey, posX float64, posY float64) (continueSearching bool)
 }
 
 type KeyProvider interface {
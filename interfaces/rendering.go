@@ -43,11 +43,39 @@ type Shape3DRenderer interface {
 	RenderShape(shape Shape3D, cameraPos types.Point3D, cameraRotation types.Rotation3D, sampler Sampler)
 }
 
+// DepthShape3DRenderer draws 3D shapes with a depth buffer, so that nearer
+// surfaces hide farther ones whatever order they are drawn in. ClearDepth
+// starts a new frame.
+type DepthShape3DRenderer interface {
+	StackRenderer
+	ClearDepth()
+	RenderShape(shape Shape3D, cameraPos types.Point3D, cameraRotation types.Rotation3D, sampler Sampler)
+}
+
 type WolfRenderer interface {
 	StackRenderer
 	RenderWorld(world types.WorldWolf, cameraPos types.Point, cameraRotation types.Degree)
 }
 
+type PreciseWolfRenderer interface {
+	WolfRenderer
+	RenderWorldPrecise(world types.WorldWolf, cameraPos types.SamplerPoint, cameraRotation types.Degree)
+}
+
+// SectorRenderer draws a sector world from cameraPos, where Z is the eye
+// height, looking along cameraRotation.
+type SectorRenderer interface {
+	StackRenderer
+	RenderSectors(world *types.WorldSector, cameraPos types.Point3D, cameraRotation types.Degree)
+}
+
+type AutomapRenderer interface {
+	StackRenderer
+	GetLineRenderer() LineRenderer
+	SetLineRenderer(lr LineRenderer)
+	RenderAutomap(world types.WorldWolf, playerPos types.SamplerPoint, playerHeading types.Degree)
+}
+
 type Shape3D interface {
 	GetPoints() []types.Point3D
 	GetSamplerPoints() map[types.Point3D]types.SamplerPoint
@@ -57,3 +85,9 @@ type GradientCreator interface {
 	StackRenderer
 	CreateGradient(color1, color2 types.Color, numSteps, startingIndex uint8) types.Gradient
 }
+
+type ReadBackRenderer interface {
+	RawRenderer
+	GetBackPixel(x uint32, y uint32) (types.PaletteIndex, error)
+	GetPaletteColor(paletteIndex types.PaletteIndex) (types.Color, error)
+}
@@ -1,3 +1,5 @@
+//go:build cgo
+
 package main
 
 import (
@@ -13,6 +15,7 @@ func init() {
 
 func main() {
 	var opengl = &impl.OpenGL{}
+	core.SelectedWolfRenderer = &impl.WolfDDARenderer{}
 	core.Init(opengl, opengl, opengl, "test")
 	core.Main()
 }
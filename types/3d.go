@@ -12,12 +12,7 @@ type Degree float64
 type Radian float64
 
 func (d Degree) ToRadians() Radian {
-	return Radian(d * (math.Pi/180))
-}
-
-type Object3D struct {
-	ID    ObjectID
-	World *World3D
+	return Radian(d * (math.Pi / 180))
 }
 
 type Rotation3D struct {
@@ -26,10 +21,6 @@ type Rotation3D struct {
 	Z Degree
 }
 
-type World3D struct {
-	Objects map[ObjectID]*Object3D
-}
-
 type ObjectID uint64
 
 type Collision3D struct {
@@ -38,16 +29,18 @@ type Collision3D struct {
 	Object2 ObjectID
 }
 
+// Ray3D is cast by the object ID from Origin.
 type Ray3D struct {
 	Origin   Point3D
 	Rotation Rotation3D
-	Object3D `collides:"false"`
+	ID       ObjectID
 }
 
+// Poly3D is a shape; it is placed in a world by an Object3D holding it as
+// its Shape.
 type Poly3D struct {
 	Points        []Point3D
 	SamplerPoints map[Point3D]SamplerPoint
-	Object3D      `collides:"true"`
 }
 
 func (p3d *Poly3D) GetPoints() []Point3D {
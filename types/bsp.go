@@ -0,0 +1,77 @@
+package types
+
+// Seg is the part of a linedef that lies in one subsector, running from Start
+// to End with its front on the right like a linedef. Front is true when the
+// seg runs the same way as its linedef, so it shows the linedef's front side.
+type Seg struct {
+	Start   Vertex
+	End     Vertex
+	Linedef int
+	Front   bool
+	// Offset is the distance from the linedef's start to the seg's start.
+	Offset float64
+}
+
+// Subsector is a convex run of Segs, from FirstSeg, that the BSP does not split further.
+type Subsector struct {
+	FirstSeg int
+	NumSegs  int
+}
+
+// BSPChild points at either a node or a subsector; subsectors are stored
+// complemented so that both fit in one value.
+type BSPChild int32
+
+func NodeChild(index int) BSPChild {
+	return BSPChild(index)
+}
+
+func SubsectorChild(index int) BSPChild {
+	return ^BSPChild(index)
+}
+
+func (bc BSPChild) IsSubsector() bool {
+	return bc < 0
+}
+
+// Index is the node or subsector index the child points at.
+func (bc BSPChild) Index() int {
+	if bc < 0 {
+		return int(^bc)
+	}
+	return int(bc)
+}
+
+// BSPNode splits space along the line through (X, Y) in direction (DX, DY).
+// Front holds everything on its right, Back everything on its left.
+type BSPNode struct {
+	X     float64
+	Y     float64
+	DX    float64
+	DY    float64
+	Front BSPChild
+	Back  BSPChild
+}
+
+// OnFront reports whether the point is on the front side of the partition line,
+// counting points on the line as in front.
+func (bn BSPNode) OnFront(x float64, y float64) bool {
+	return bn.DX*(y-bn.Y)-bn.DY*(x-bn.X) <= 0
+}
+
+type BSPTree struct {
+	Segs       []Seg
+	Subsectors []Subsector
+	Nodes      []BSPNode
+	Root       BSPChild
+	// Linedefs is the number of linedefs in the world the tree was built for.
+	Linedefs int
+}
+
+// ValidChild reports whether child points at a node or subsector in the tree.
+func (bt *BSPTree) ValidChild(child BSPChild) bool {
+	if child.IsSubsector() {
+		return child.Index() < len(bt.Subsectors)
+	}
+	return child.Index() < len(bt.Nodes)
+}
@@ -39,6 +39,8 @@ const MAX_UINT6 uint6 = (1 << 6) - 1
 var (
 	ErrInvalidUint6 = errors.New("invalid uint6")
 	ErrInvalidColor = errors.New("invalid color")
+	// ErrUnsetColor is returned when reading a palette entry nothing was set to.
+	ErrUnsetColor = errors.New("palette color not set")
 )
 
 func FromRGB(r uint6, g uint6, b uint6) (Color, error) {
@@ -61,3 +63,53 @@ func FromRGBNoErr(r uint6, g uint6, b uint6) Color {
 	}
 	return out
 }
+
+// FromRGBUint8 is FromRGB for callers outside this package, which cannot name
+// uint6. The channels must already be in the 6-bit range.
+func FromRGBUint8(r uint8, g uint8, b uint8) (Color, error) {
+	return FromRGB(uint6(r), uint6(g), uint6(b))
+}
+
+// Uint8ToUint6 scales an 8-bit channel down to the 6-bit range, rounding to nearest.
+func Uint8ToUint6(value uint8) uint8 {
+	return uint8((uint16(value)*uint16(MAX_UINT6) + 127) / 255)
+}
+
+// Uint6ToUint8 scales a 6-bit channel up to the 8-bit range, rounding to
+// nearest, so that Uint8ToUint6(Uint6ToUint8(v)) == v.
+func Uint6ToUint8(value uint8) uint8 {
+	return uint8((uint16(value)*255 + uint16(MAX_UINT6)/2) / uint16(MAX_UINT6))
+}
+
+// FromRGB8 builds a Color from full 8-bit channels.
+func FromRGB8(r uint8, g uint8, b uint8) Color {
+	return Color{R: uint6(Uint8ToUint6(r)), G: uint6(Uint8ToUint6(g)), B: uint6(Uint8ToUint6(b))}
+}
+
+// RGB8 returns the color's channels scaled up to 8 bits.
+func (clr Color) RGB8() (uint8, uint8, uint8) {
+	return Uint6ToUint8(uint8(clr.R)), Uint6ToUint8(uint8(clr.G)), Uint6ToUint8(uint8(clr.B))
+}
+
+type Palette [256]Color
+
+func NewInvalidPalette() Palette {
+	var out Palette
+	for i := range out {
+		out[i] = InvalidColor
+	}
+	return out
+}
+
+// RGBA lets a Color be used wherever an image/color.Color is expected,
+// scaling each channel up as RGB8 does. Invalid colors are fully transparent.
+func (clr Color) RGBA() (r, g, b, a uint32) {
+	if !clr.IsValid() {
+		return 0, 0, 0, 0
+	}
+	var r8, g8, b8 = clr.RGB8()
+	var expand = func(c8 uint8) uint32 {
+		return uint32(c8)<<8 | uint32(c8)
+	}
+	return expand(r8), expand(g8), expand(b8), 0xffff
+}
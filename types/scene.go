@@ -0,0 +1,281 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/averseabfun/flux/vecmath"
+)
+
+// Shape mirrors interfaces.Shape3D, which this package cannot import.
+type Shape interface {
+	GetPoints() []Point3D
+	GetSamplerPoints() map[Point3D]SamplerPoint
+}
+
+// Transform places an object relative to its parent: scaled, then rotated,
+// then moved to Position. A zero Scale counts as 1 on every axis.
+type Transform struct {
+	Position Point3D
+	Rotation Rotation3D
+	Scale    Point3D
+}
+
+func (t Transform) Matrix() vecmath.Mat4 {
+	var scale = t.Scale
+	if scale == (Point3D{}) {
+		scale = Point3D{X: 1, Y: 1, Z: 1}
+	}
+	return vecmath.Translate(t.Position.Vec3()).Mul(t.Rotation.Matrix()).Mul(vecmath.Scale(scale.Vec3()))
+}
+
+// NoObject is the ID of no object, used as the parent of root objects.
+const NoObject ObjectID = 0
+
+type Object3D struct {
+	ID        ObjectID
+	World     *World3D
+	Name      string
+	Transform Transform
+	// Hidden hides the object and all of its children.
+	Hidden  bool
+	Shape   Shape
+	Sampler Sampler
+
+	parent   ObjectID
+	children []ObjectID
+}
+
+type World3D struct {
+	objects map[ObjectID]*Object3D
+	nextID  ObjectID
+	roots   []ObjectID
+}
+
+var (
+	ErrObjectNotFound = errors.New("object not found")
+	ErrObjectIDTaken  = errors.New("object ID already in use")
+	ErrObjectInWorld  = errors.New("object already belongs to a world")
+	ErrObjectCycle    = errors.New("object would become its own ancestor")
+)
+
+func NewWorld3D() *World3D {
+	return &World3D{objects: make(map[ObjectID]*Object3D), nextID: 1}
+}
+
+// Add puts object into the world at the root. An object with a zero ID is
+// given the next free one; objects keep their IDs until removed, and IDs are
+// never handed out twice.
+func (w *World3D) Add(object *Object3D) (ObjectID, error) {
+	return w.AddChild(NoObject, object)
+}
+
+// AddChild puts object into the world under parent, or at the root if parent is NoObject.
+func (w *World3D) AddChild(parent ObjectID, object *Object3D) (ObjectID, error) {
+	if w.objects == nil {
+		w.objects = make(map[ObjectID]*Object3D)
+	}
+	if object.World != nil {
+		return NoObject, fmt.Errorf("%w: %d", ErrObjectInWorld, object.ID)
+	}
+	if _, ok := w.objects[parent]; parent != NoObject && !ok {
+		return NoObject, fmt.Errorf("%w: parent %d", ErrObjectNotFound, parent)
+	}
+	if object.ID == NoObject {
+		object.ID = max(w.nextID, 1)
+	}
+	if _, ok := w.objects[object.ID]; ok {
+		return NoObject, fmt.Errorf("%w: %d", ErrObjectIDTaken, object.ID)
+	}
+	w.nextID = max(w.nextID, object.ID+1)
+	object.World = w
+	object.parent = NoObject
+	object.children = nil
+	w.objects[object.ID] = object
+	w.link(object, parent)
+	return object.ID, nil
+}
+
+func (w *World3D) link(object *Object3D, parent ObjectID) {
+	object.parent = parent
+	if parent == NoObject {
+		w.roots = append(w.roots, object.ID)
+		return
+	}
+	var parentObject = w.objects[parent]
+	parentObject.children = append(parentObject.children, object.ID)
+}
+
+func (w *World3D) unlink(object *Object3D) {
+	if object.parent == NoObject {
+		w.roots = slices.DeleteFunc(w.roots, func(id ObjectID) bool { return id == object.ID })
+		return
+	}
+	var parent = w.objects[object.parent]
+	parent.children = slices.DeleteFunc(parent.children, func(id ObjectID) bool { return id == object.ID })
+}
+
+func (w *World3D) Get(id ObjectID) (*Object3D, bool) {
+	var object, ok = w.objects[id]
+	return object, ok
+}
+
+// Remove takes an object and all of its descendants out of the world.
+func (w *World3D) Remove(id ObjectID) error {
+	var object, ok = w.objects[id]
+	if !ok {
+		return fmt.Errorf("%w: %d", ErrObjectNotFound, id)
+	}
+	w.unlink(object)
+	w.removeTree(object)
+	return nil
+}
+
+func (w *World3D) removeTree(object *Object3D) {
+	for _, child := range object.children {
+		w.removeTree(w.objects[child])
+	}
+	delete(w.objects, object.ID)
+	object.World, object.parent, object.children = nil, NoObject, nil
+}
+
+// SetParent moves an object, with its children, under parent, or to the root
+// if parent is NoObject. Its local transform is kept, so it moves with its
+// new parent.
+func (w *World3D) SetParent(id ObjectID, parent ObjectID) error {
+	var object, ok = w.objects[id]
+	if !ok {
+		return fmt.Errorf("%w: %d", ErrObjectNotFound, id)
+	}
+	if _, ok := w.objects[parent]; parent != NoObject && !ok {
+		return fmt.Errorf("%w: parent %d", ErrObjectNotFound, parent)
+	}
+	for ancestor := parent; ancestor != NoObject; ancestor = w.objects[ancestor].parent {
+		if ancestor == id {
+			return fmt.Errorf("%w: %d under %d", ErrObjectCycle, id, parent)
+		}
+	}
+	w.unlink(object)
+	w.link(object, parent)
+	return nil
+}
+
+// Len is the number of objects in the world.
+func (w *World3D) Len() int {
+	return len(w.objects)
+}
+
+// IDs lists every object's ID in ascending order.
+func (w *World3D) IDs() []ObjectID {
+	var out = make([]ObjectID, 0, len(w.objects))
+	for id := range w.objects {
+		out = append(out, id)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// Each calls fn for every object in ascending ID order until fn returns false.
+func (w *World3D) Each(fn func(object *Object3D) bool) {
+	for _, id := range w.IDs() {
+		if !fn(w.objects[id]) {
+			return
+		}
+	}
+}
+
+// Roots lists the objects without a parent, in the order they were added.
+func (w *World3D) Roots() []*Object3D {
+	var out = make([]*Object3D, len(w.roots))
+	for i, id := range w.roots {
+		out[i] = w.objects[id]
+	}
+	return out
+}
+
+// Walk visits every visible object depth first, parents before children and
+// siblings in the order they were added, with each object's world matrix.
+func (w *World3D) Walk(fn func(object *Object3D, world vecmath.Mat4)) {
+	for _, root := range w.Roots() {
+		root.walk(vecmath.Identity4(), fn)
+	}
+}
+
+func (o *Object3D) walk(parent vecmath.Mat4, fn func(object *Object3D, world vecmath.Mat4)) {
+	if o.Hidden {
+		return
+	}
+	var world = parent.Mul(o.Transform.Matrix())
+	fn(o, world)
+	for _, child := range o.Children() {
+		child.walk(world, fn)
+	}
+}
+
+// Parent returns the object's parent, or nil for root objects and objects
+// that are not in a world.
+func (o *Object3D) Parent() *Object3D {
+	if o.World == nil || o.parent == NoObject {
+		return nil
+	}
+	return o.World.objects[o.parent]
+}
+
+func (o *Object3D) Children() []*Object3D {
+	var out = make([]*Object3D, len(o.children))
+	for i, id := range o.children {
+		out[i] = o.World.objects[id]
+	}
+	return out
+}
+
+func (o *Object3D) LocalMatrix() vecmath.Mat4 {
+	return o.Transform.Matrix()
+}
+
+// WorldMatrix places the object in the world, combining the transforms of
+// all of its ancestors.
+func (o *Object3D) WorldMatrix() vecmath.Mat4 {
+	var out = o.Transform.Matrix()
+	for parent := o.Parent(); parent != nil; parent = parent.Parent() {
+		out = parent.Transform.Matrix().Mul(out)
+	}
+	return out
+}
+
+// WorldPosition is where the object's origin is in the world.
+func (o *Object3D) WorldPosition() Point3D {
+	return Point3DFromVec3(o.WorldMatrix().Translation())
+}
+
+// IsVisible reports whether neither the object nor any of its ancestors is hidden.
+func (o *Object3D) IsVisible() bool {
+	for object := o; object != nil; object = object.Parent() {
+		if object.Hidden {
+			return false
+		}
+	}
+	return true
+}
+
+// WorldShape is the object's shape moved by matrix, normally its
+// WorldMatrix, or nil if it has no shape.
+func (o *Object3D) WorldShape(matrix vecmath.Mat4) *Poly3D {
+	if o.Shape == nil {
+		return nil
+	}
+	var points = o.Shape.GetPoints()
+	var samplerPoints = o.Shape.GetSamplerPoints()
+	var out = &Poly3D{Points: make([]Point3D, len(points))}
+	if samplerPoints != nil {
+		out.SamplerPoints = make(map[Point3D]SamplerPoint, len(samplerPoints))
+	}
+	for i, point := range points {
+		out.Points[i] = Point3DFromVec3(matrix.MulPoint(point.Vec3()))
+		if uv, ok := samplerPoints[point]; ok {
+			out.SamplerPoints[out.Points[i]] = uv
+		}
+	}
+	return out
+}
@@ -0,0 +1,100 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sector worlds use Doom's conventions: Y grows upward (north), angles turn
+// counterclockwise, and a linedef's front side is on the right when walking
+// from its Start vertex to its End vertex.
+
+const NoSidedef = -1
+
+type Vertex struct {
+	X float64
+	Y float64
+}
+
+type Sector struct {
+	FloorHeight    float64
+	CeilingHeight  float64
+	FloorColor     PaletteIndex
+	CeilingColor   PaletteIndex
+	FloorSampler   Sampler
+	CeilingSampler Sampler
+	FloorTexture   string
+	CeilingTexture string
+	// Darkness is the sector's light value, where 0 is fully lit and 255 is darkest.
+	Darkness uint8
+}
+
+// Sidedef is one side of a linedef, facing into Sector. Upper and Lower are
+// the parts of a two-sided line above and below the opening into the sector
+// behind it; Middle is the wall of a one-sided line. Parts without a sampler
+// are drawn flat with Color.
+type Sidedef struct {
+	Sector  int
+	OffsetX float64
+	OffsetY float64
+	Color   PaletteIndex
+	Upper   Sampler
+	Middle  Sampler
+	Lower   Sampler
+
+	UpperTexture  string
+	MiddleTexture string
+	LowerTexture  string
+}
+
+type Linedef struct {
+	Start int
+	End   int
+	Front int
+	Back  int // NoSidedef for one-sided lines
+}
+
+func (ld Linedef) IsTwoSided() bool {
+	return ld.Back != NoSidedef
+}
+
+// Thing is a map object placed in the world, such as a player start,
+// monster or item; Type says which.
+type Thing struct {
+	X     float64
+	Y     float64
+	Angle Degree
+	Type  uint16
+	Flags uint16
+}
+
+type WorldSector struct {
+	Vertices []Vertex
+	Linedefs []Linedef
+	Sidedefs []Sidedef
+	Sectors  []Sector
+	Things   []Thing
+}
+
+var ErrInvalidSectorWorld = errors.New("invalid sector world")
+
+// Validate checks that every index in the world points at something.
+func (ws *WorldSector) Validate() error {
+	for i, line := range ws.Linedefs {
+		if line.Start < 0 || line.Start >= len(ws.Vertices) || line.End < 0 || line.End >= len(ws.Vertices) {
+			return fmt.Errorf("%w: linedef %d has a vertex out of range", ErrInvalidSectorWorld, i)
+		}
+		if line.Front < 0 || line.Front >= len(ws.Sidedefs) {
+			return fmt.Errorf("%w: linedef %d has no valid front sidedef", ErrInvalidSectorWorld, i)
+		}
+		if line.Back != NoSidedef && (line.Back < 0 || line.Back >= len(ws.Sidedefs)) {
+			return fmt.Errorf("%w: linedef %d has a back sidedef out of range", ErrInvalidSectorWorld, i)
+		}
+	}
+	for i, side := range ws.Sidedefs {
+		if side.Sector < 0 || side.Sector >= len(ws.Sectors) {
+			return fmt.Errorf("%w: sidedef %d has a sector out of range", ErrInvalidSectorWorld, i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package types
+
+import "github.com/averseabfun/flux/vecmath"
+
+func (p Point3D) Vec3() vecmath.Vec3 {
+	return vecmath.Vec3{X: p.X, Y: p.Y, Z: p.Z}
+}
+
+func Point3DFromVec3(v vecmath.Vec3) Point3D {
+	return Point3D{X: v.X, Y: v.Y, Z: v.Z}
+}
+
+// Matrix is the rotation as a matrix: X is pitch (positive looks up), Y is
+// yaw (positive turns right) and Z is roll, applied yaw first.
+func (r Rotation3D) Matrix() vecmath.Mat4 {
+	return vecmath.RotateEuler(float64(r.X.ToRadians()), float64(r.Y.ToRadians()), float64(r.Z.ToRadians()))
+}
+
+func (r Rotation3D) Quat() vecmath.Quat {
+	return vecmath.QuatFromEuler(float64(r.X.ToRadians()), float64(r.Y.ToRadians()), float64(r.Z.ToRadians()))
+}
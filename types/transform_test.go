@@ -0,0 +1,49 @@
+package types
+
+import (
+	"math"
+	"testing"
+
+	"github.com/averseabfun/flux/vecmath"
+)
+
+func TestPoint3DVec3(t *testing.T) {
+	var tests = []Point3D{
+		{},
+		{X: 1, Y: -2, Z: 3},
+		{X: 1e-12, Y: 1e12, Z: -0.5},
+		{X: math.MaxFloat64, Y: -math.SmallestNonzeroFloat64, Z: math.Pi},
+	}
+	for _, point := range tests {
+		var v = point.Vec3()
+		if v.X != point.X || v.Y != point.Y || v.Z != point.Z {
+			t.Errorf("%v.Vec3() = %v", point, v)
+		}
+		if got := Point3DFromVec3(v); got != point {
+			t.Errorf("Point3DFromVec3(%v) = %v, want %v", v, got, point)
+		}
+	}
+}
+
+func TestRotation3D(t *testing.T) {
+	var tests = []struct {
+		name     string
+		rotation Rotation3D
+		v        vecmath.Vec3
+		want     vecmath.Vec3
+	}{
+		{"none", Rotation3D{}, vecmath.Vec3{X: 1, Y: 2, Z: 3}, vecmath.Vec3{X: 1, Y: 2, Z: 3}},
+		{"yaw right", Rotation3D{Y: 90}, vecmath.Vec3{Z: 1}, vecmath.Vec3{X: 1}},
+		{"pitch up", Rotation3D{X: 90}, vecmath.Vec3{Z: 1}, vecmath.Vec3{Y: 1}},
+		{"roll", Rotation3D{Z: 90}, vecmath.Vec3{X: 1}, vecmath.Vec3{Y: 1}},
+		{"yaw then pitch", Rotation3D{X: 45, Y: 180}, vecmath.Vec3{Z: 1}, vecmath.Vec3{Y: math.Sqrt2 / 2, Z: -math.Sqrt2 / 2}},
+	}
+	for _, test := range tests {
+		if got := test.rotation.Matrix().MulDirection(test.v); !got.ApproxEqual(test.want) {
+			t.Errorf("%s: Matrix got %v, want %v", test.name, got, test.want)
+		}
+		if got := test.rotation.Quat().Rotate(test.v); !got.ApproxEqual(test.want) {
+			t.Errorf("%s: Quat got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package types
 
+import "math"
+
 type Side uint8
+
 const (
 	SideTop = Side(iota)
 	SideLeft
@@ -8,15 +11,90 @@ const (
 	SideBottom
 )
 
+// Sampler has the same method set as interfaces.Sampler, so any sampler can
+// be stored in world types without an import cycle.
+type Sampler interface {
+	GetAtPoint(point SamplerPoint) PaletteIndex
+}
+
 type RectWolf struct {
 	Start Point // Top-down view
-	End Point   // Top-down view
+	End   Point // Top-down view
 	Color PaletteIndex
-	
-	ID ObjectID
+	// Sampler textures every side of the wall, unless overridden for a side
+	// in SideSamplers. Walls without either are drawn flat with Color.
+	Sampler      Sampler
+	SideSamplers map[Side]Sampler
+	// Darkness is the wall's light value added to the world's, where 0 is
+	// fully lit and 255 is darkest.
+	Darkness uint8
+
+	ID    ObjectID
 	World *WorldWolf
 }
 
+func (rw *RectWolf) GetSampler(side Side) Sampler {
+	if sampler, ok := rw.SideSamplers[side]; ok && sampler != nil {
+		return sampler
+	}
+	return rw.Sampler
+}
+
+// SpriteWolf is a billboard standing on the floor, always facing the camera.
+// Width and Height are in world units; a zero Width is one unit and a zero
+// Height is as tall as the walls. Pixels sampled as Transparent are skipped
+// when HasTransparency is set.
+type SpriteWolf struct {
+	Position        SamplerPoint // Top-down view
+	Sampler         Sampler
+	Width           float64
+	Height          float64
+	Transparent     PaletteIndex
+	HasTransparency bool
+	Darkness        uint8
+
+	ID    ObjectID
+	World *WorldWolf
+}
+
+// WolfSurface is a floor or ceiling. When enabled it is drawn flat with Color,
+// or textured with Sampler repeating every Scale world units (1 if zero).
+type WolfSurface struct {
+	Enabled bool
+	Color   PaletteIndex
+	Sampler Sampler
+	Scale   float64
+}
+
+// ColorAt samples the surface at a world position.
+func (ws WolfSurface) ColorAt(x float64, y float64) PaletteIndex {
+	if ws.Sampler == nil {
+		return ws.Color
+	}
+	var scale = ws.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	x /= scale
+	y /= scale
+	return ws.Sampler.GetAtPoint(SamplerPoint{X: x - math.Floor(x), Y: y - math.Floor(y)})
+}
+
 type WorldWolf struct {
 	Objects map[ObjectID]*RectWolf
-}
\ No newline at end of file
+	Sprites map[ObjectID]*SpriteWolf
+	Floor   WolfSurface
+	Ceiling WolfSurface
+	// Darkness is the light value of the whole world, applied to the floor
+	// and ceiling and added to every wall's and sprite's own.
+	Darkness uint8
+
+	PlayerStart   SamplerPoint
+	PlayerHeading Degree
+	// Palette holds the colors the map wants set before it is rendered.
+	Palette map[PaletteIndex]Color
+}
+
+func (ww WorldWolf) AddDarkness(darkness uint8) uint8 {
+	return uint8(min(int(ww.Darkness)+int(darkness), 255))
+}
@@ -0,0 +1,279 @@
+package vecmath
+
+import "math"
+
+// Mat3 is indexed [row][column].
+type Mat3 [3][3]float64
+
+// Mat4 is indexed [row][column]; translations live in the last column.
+type Mat4 [4][4]float64
+
+func Identity3() Mat3 {
+	return Mat3{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+}
+
+func (m Mat3) Mul(o Mat3) Mat3 {
+	var out Mat3
+	for row := 0; row < 3; row++ {
+		for column := 0; column < 3; column++ {
+			for i := 0; i < 3; i++ {
+				out[row][column] += m[row][i] * o[i][column]
+			}
+		}
+	}
+	return out
+}
+
+func (m Mat3) MulVec3(v Vec3) Vec3 {
+	return Vec3{
+		X: m[0][0]*v.X + m[0][1]*v.Y + m[0][2]*v.Z,
+		Y: m[1][0]*v.X + m[1][1]*v.Y + m[1][2]*v.Z,
+		Z: m[2][0]*v.X + m[2][1]*v.Y + m[2][2]*v.Z,
+	}
+}
+
+func (m Mat3) Transpose() Mat3 {
+	var out Mat3
+	for row := 0; row < 3; row++ {
+		for column := 0; column < 3; column++ {
+			out[column][row] = m[row][column]
+		}
+	}
+	return out
+}
+
+func (m Mat3) Determinant() float64 {
+	return m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+}
+
+// Inverse returns the inverse of m, and false if m is singular.
+func (m Mat3) Inverse() (Mat3, bool) {
+	var det = m.Determinant()
+	if math.Abs(det) < Epsilon {
+		return Mat3{}, false
+	}
+	var out = Mat3{
+		{m[1][1]*m[2][2] - m[1][2]*m[2][1], m[0][2]*m[2][1] - m[0][1]*m[2][2], m[0][1]*m[1][2] - m[0][2]*m[1][1]},
+		{m[1][2]*m[2][0] - m[1][0]*m[2][2], m[0][0]*m[2][2] - m[0][2]*m[2][0], m[0][2]*m[1][0] - m[0][0]*m[1][2]},
+		{m[1][0]*m[2][1] - m[1][1]*m[2][0], m[0][1]*m[2][0] - m[0][0]*m[2][1], m[0][0]*m[1][1] - m[0][1]*m[1][0]},
+	}
+	for row := range out {
+		for column := range out[row] {
+			out[row][column] /= det
+		}
+	}
+	return out, true
+}
+
+// Mat4 embeds m as the rotation part of an affine matrix.
+func (m Mat3) Mat4() Mat4 {
+	var out = Identity4()
+	for row := 0; row < 3; row++ {
+		copy(out[row][:3], m[row][:])
+	}
+	return out
+}
+
+func (m Mat3) ApproxEqual(o Mat3) bool {
+	for row := range m {
+		for column := range m[row] {
+			if !approxEqual(m[row][column], o[row][column]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func Identity4() Mat4 {
+	return Mat4{{1, 0, 0, 0}, {0, 1, 0, 0}, {0, 0, 1, 0}, {0, 0, 0, 1}}
+}
+
+func Translate(v Vec3) Mat4 {
+	var out = Identity4()
+	out[0][3], out[1][3], out[2][3] = v.X, v.Y, v.Z
+	return out
+}
+
+func Scale(v Vec3) Mat4 {
+	var out = Identity4()
+	out[0][0], out[1][1], out[2][2] = v.X, v.Y, v.Z
+	return out
+}
+
+// RotateX rotates by angle radians about +X, turning +Y toward +Z.
+func RotateX(angle float64) Mat4 {
+	var sin, cos = math.Sincos(angle)
+	return Mat4{{1, 0, 0, 0}, {0, cos, -sin, 0}, {0, sin, cos, 0}, {0, 0, 0, 1}}
+}
+
+// RotateY rotates by angle radians about +Y, turning +Z toward +X.
+func RotateY(angle float64) Mat4 {
+	var sin, cos = math.Sincos(angle)
+	return Mat4{{cos, 0, sin, 0}, {0, 1, 0, 0}, {-sin, 0, cos, 0}, {0, 0, 0, 1}}
+}
+
+// RotateZ rotates by angle radians about +Z, turning +X toward +Y.
+func RotateZ(angle float64) Mat4 {
+	var sin, cos = math.Sincos(angle)
+	return Mat4{{cos, -sin, 0, 0}, {sin, cos, 0, 0}, {0, 0, 1, 0}, {0, 0, 0, 1}}
+}
+
+// RotateEuler orients something by yaw about Y, then pitch about its own X,
+// then roll about its own Z. Positive yaw turns +Z toward +X and positive
+// pitch turns +Z toward +Y.
+func RotateEuler(pitch float64, yaw float64, roll float64) Mat4 {
+	return RotateY(yaw).Mul(RotateX(-pitch)).Mul(RotateZ(roll))
+}
+
+// LookAt builds a view matrix for a camera at eye looking at target, which
+// maps eye to the origin and the view direction to +Z. It returns false if
+// eye and target coincide or the view direction is parallel to up, since no
+// orientation follows from those.
+func LookAt(eye Vec3, target Vec3, up Vec3) (Mat4, bool) {
+	var forward = target.Sub(eye).Normalize()
+	var right = up.Cross(forward).Normalize()
+	if forward == (Vec3{}) || right == (Vec3{}) {
+		return Identity4(), false
+	}
+	var trueUp = forward.Cross(right)
+	return Mat4{
+		{right.X, right.Y, right.Z, -right.Dot(eye)},
+		{trueUp.X, trueUp.Y, trueUp.Z, -trueUp.Dot(eye)},
+		{forward.X, forward.Y, forward.Z, -forward.Dot(eye)},
+		{0, 0, 0, 1},
+	}, true
+}
+
+// Perspective builds a projection from view space, looking down +Z, to clip
+// space. fovY is the vertical field of view in radians. After the
+// perspective divide X and Y run from -1 to 1 and depth runs from 0 at near
+// to 1 at far.
+func Perspective(fovY float64, aspect float64, near float64, far float64) Mat4 {
+	var f = 1 / math.Tan(fovY/2)
+	return Mat4{
+		{f / aspect, 0, 0, 0},
+		{0, f, 0, 0},
+		{0, 0, far / (far - near), -near * far / (far - near)},
+		{0, 0, 1, 0},
+	}
+}
+
+func (m Mat4) Mul(o Mat4) Mat4 {
+	var out Mat4
+	for row := 0; row < 4; row++ {
+		for column := 0; column < 4; column++ {
+			for i := 0; i < 4; i++ {
+				out[row][column] += m[row][i] * o[i][column]
+			}
+		}
+	}
+	return out
+}
+
+func (m Mat4) MulVec4(v Vec4) Vec4 {
+	return Vec4{
+		X: m[0][0]*v.X + m[0][1]*v.Y + m[0][2]*v.Z + m[0][3]*v.W,
+		Y: m[1][0]*v.X + m[1][1]*v.Y + m[1][2]*v.Z + m[1][3]*v.W,
+		Z: m[2][0]*v.X + m[2][1]*v.Y + m[2][2]*v.Z + m[2][3]*v.W,
+		W: m[3][0]*v.X + m[3][1]*v.Y + m[3][2]*v.Z + m[3][3]*v.W,
+	}
+}
+
+// MulPoint transforms a position, dividing by W if m is projective.
+func (m Mat4) MulPoint(v Vec3) Vec3 {
+	var out = m.MulVec4(v.Vec4(1))
+	if out.W != 1 && out.W != 0 {
+		return out.PerspectiveDivide()
+	}
+	return out.Vec3()
+}
+
+// MulDirection transforms a direction, ignoring translation.
+func (m Mat4) MulDirection(v Vec3) Vec3 {
+	return m.MulVec4(v.Vec4(0)).Vec3()
+}
+
+func (m Mat4) Transpose() Mat4 {
+	var out Mat4
+	for row := 0; row < 4; row++ {
+		for column := 0; column < 4; column++ {
+			out[column][row] = m[row][column]
+		}
+	}
+	return out
+}
+
+// Mat3 is the upper-left 3x3 part of m.
+func (m Mat4) Mat3() Mat3 {
+	var out Mat3
+	for row := 0; row < 3; row++ {
+		copy(out[row][:], m[row][:3])
+	}
+	return out
+}
+
+// Translation is the translation part of m.
+func (m Mat4) Translation() Vec3 {
+	return Vec3{X: m[0][3], Y: m[1][3], Z: m[2][3]}
+}
+
+// Inverse returns the inverse of m, and false if m is singular.
+func (m Mat4) Inverse() (Mat4, bool) {
+	var s0 = m[0][0]*m[1][1] - m[1][0]*m[0][1]
+	var s1 = m[0][0]*m[1][2] - m[1][0]*m[0][2]
+	var s2 = m[0][0]*m[1][3] - m[1][0]*m[0][3]
+	var s3 = m[0][1]*m[1][2] - m[1][1]*m[0][2]
+	var s4 = m[0][1]*m[1][3] - m[1][1]*m[0][3]
+	var s5 = m[0][2]*m[1][3] - m[1][2]*m[0][3]
+	var c5 = m[2][2]*m[3][3] - m[3][2]*m[2][3]
+	var c4 = m[2][1]*m[3][3] - m[3][1]*m[2][3]
+	var c3 = m[2][1]*m[3][2] - m[3][1]*m[2][2]
+	var c2 = m[2][0]*m[3][3] - m[3][0]*m[2][3]
+	var c1 = m[2][0]*m[3][2] - m[3][0]*m[2][2]
+	var c0 = m[2][0]*m[3][1] - m[3][0]*m[2][1]
+	var det = s0*c5 - s1*c4 + s2*c3 + s3*c2 - s4*c1 + s5*c0
+	if math.Abs(det) < Epsilon {
+		return Mat4{}, false
+	}
+	var inv = 1 / det
+	return Mat4{
+		{
+			(m[1][1]*c5 - m[1][2]*c4 + m[1][3]*c3) * inv,
+			(-m[0][1]*c5 + m[0][2]*c4 - m[0][3]*c3) * inv,
+			(m[3][1]*s5 - m[3][2]*s4 + m[3][3]*s3) * inv,
+			(-m[2][1]*s5 + m[2][2]*s4 - m[2][3]*s3) * inv,
+		},
+		{
+			(-m[1][0]*c5 + m[1][2]*c2 - m[1][3]*c1) * inv,
+			(m[0][0]*c5 - m[0][2]*c2 + m[0][3]*c1) * inv,
+			(-m[3][0]*s5 + m[3][2]*s2 - m[3][3]*s1) * inv,
+			(m[2][0]*s5 - m[2][2]*s2 + m[2][3]*s1) * inv,
+		},
+		{
+			(m[1][0]*c4 - m[1][1]*c2 + m[1][3]*c0) * inv,
+			(-m[0][0]*c4 + m[0][1]*c2 - m[0][3]*c0) * inv,
+			(m[3][0]*s4 - m[3][1]*s2 + m[3][3]*s0) * inv,
+			(-m[2][0]*s4 + m[2][1]*s2 - m[2][3]*s0) * inv,
+		},
+		{
+			(-m[1][0]*c3 + m[1][1]*c1 - m[1][2]*c0) * inv,
+			(m[0][0]*c3 - m[0][1]*c1 + m[0][2]*c0) * inv,
+			(-m[3][0]*s3 + m[3][1]*s1 - m[3][2]*s0) * inv,
+			(m[2][0]*s3 - m[2][1]*s1 + m[2][2]*s0) * inv,
+		},
+	}, true
+}
+
+func (m Mat4) ApproxEqual(o Mat4) bool {
+	for row := range m {
+		for column := range m[row] {
+			if !approxEqual(m[row][column], o[row][column]) {
+				return false
+			}
+		}
+	}
+	return true
+}
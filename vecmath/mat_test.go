@@ -0,0 +1,194 @@
+package vecmath
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMat4Inverse(t *testing.T) {
+	var tests = []struct {
+		name string
+		m    Mat4
+		want Mat4
+	}{
+		{"identity", Identity4(), Identity4()},
+		{"translate", Translate(Vec3{1, -2, 3}), Translate(Vec3{-1, 2, -3})},
+		{"scale", Scale(Vec3{2, 4, -8}), Scale(Vec3{0.5, 0.25, -0.125})},
+		{"rotate x", RotateX(0.3), RotateX(-0.3)},
+		{"rotate y", RotateY(1.2), RotateY(-1.2)},
+		{"rotate z", RotateZ(-2), RotateZ(2)},
+		{
+			"affine",
+			Translate(Vec3{5, 6, 7}).Mul(RotateY(0.7)).Mul(Scale(Vec3{2, 2, 2})),
+			Scale(Vec3{0.5, 0.5, 0.5}).Mul(RotateY(-0.7)).Mul(Translate(Vec3{-5, -6, -7})),
+		},
+		{
+			"general",
+			Mat4{{2, 0, 0, 1}, {0, 1, 0, 0}, {0, 0, 1, 0}, {1, 0, 0, 1}},
+			Mat4{{1, 0, 0, -1}, {0, 1, 0, 0}, {0, 0, 1, 0}, {-1, 0, 0, 2}},
+		},
+	}
+	for _, test := range tests {
+		var got, ok = test.m.Inverse()
+		if !ok {
+			t.Errorf("%s: reported singular", test.name)
+			continue
+		}
+		if !got.ApproxEqual(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+		if !test.m.Mul(got).ApproxEqual(Identity4()) {
+			t.Errorf("%s: m * inverse is not the identity", test.name)
+		}
+	}
+}
+
+func TestMat4InverseSingular(t *testing.T) {
+	var tests = []struct {
+		name string
+		m    Mat4
+	}{
+		{"zero", Mat4{}},
+		{"flat scale", Scale(Vec3{1, 0, 1})},
+		{"repeated row", Mat4{{1, 2, 3, 4}, {1, 2, 3, 4}, {0, 0, 1, 0}, {0, 0, 0, 1}}},
+		{"dependent columns", Mat4{{1, 2, 0, 0}, {2, 4, 0, 0}, {3, 6, 1, 0}, {4, 8, 0, 1}}},
+	}
+	for _, test := range tests {
+		if _, ok := test.m.Inverse(); ok {
+			t.Errorf("%s: expected singular", test.name)
+		}
+	}
+}
+
+func TestMat3Inverse(t *testing.T) {
+	var tests = []struct {
+		name string
+		m    Mat3
+		want Mat3
+		ok   bool
+	}{
+		{"identity", Identity3(), Identity3(), true},
+		{"diagonal", Mat3{{2, 0, 0}, {0, 4, 0}, {0, 0, 5}}, Mat3{{0.5, 0, 0}, {0, 0.25, 0}, {0, 0, 0.2}}, true},
+		{"general", Mat3{{1, 2, 3}, {0, 1, 4}, {5, 6, 0}}, Mat3{{-24, 18, 5}, {20, -15, -4}, {-5, 4, 1}}, true},
+		{"rotation", RotateZ(0.4).Mat3(), RotateZ(-0.4).Mat3(), true},
+		{"zero", Mat3{}, Mat3{}, false},
+		{"repeated row", Mat3{{1, 2, 3}, {1, 2, 3}, {0, 0, 1}}, Mat3{}, false},
+		{"dependent rows", Mat3{{1, 2, 3}, {4, 5, 6}, {7, 8, 9}}, Mat3{}, false},
+	}
+	for _, test := range tests {
+		var got, ok = test.m.Inverse()
+		if ok != test.ok {
+			t.Errorf("%s: ok = %v, want %v", test.name, ok, test.ok)
+			continue
+		}
+		if ok && !got.ApproxEqual(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestMat4Transform(t *testing.T) {
+	var tests = []struct {
+		name string
+		got  Vec3
+		want Vec3
+	}{
+		{"translate point", Translate(Vec3{1, 2, 3}).MulPoint(Vec3{1, 1, 1}), Vec3{2, 3, 4}},
+		{"translate direction", Translate(Vec3{1, 2, 3}).MulDirection(Vec3{1, 1, 1}), Vec3{1, 1, 1}},
+		{"scale point", Scale(Vec3{2, 3, 4}).MulPoint(Vec3{1, 1, 1}), Vec3{2, 3, 4}},
+		{"rotate x", RotateX(math.Pi / 2).MulDirection(Vec3{Y: 1}), Vec3{Z: 1}},
+		{"rotate y", RotateY(math.Pi / 2).MulDirection(Vec3{Z: 1}), Vec3{X: 1}},
+		{"rotate z", RotateZ(math.Pi / 2).MulDirection(Vec3{X: 1}), Vec3{Y: 1}},
+		{"euler yaw", RotateEuler(0, math.Pi/2, 0).MulDirection(Vec3{Z: 1}), Vec3{X: 1}},
+		{"euler pitch", RotateEuler(math.Pi/2, 0, 0).MulDirection(Vec3{Z: 1}), Vec3{Y: 1}},
+		{"euler roll", RotateEuler(0, 0, math.Pi/2).MulDirection(Vec3{X: 1}), Vec3{Y: 1}},
+		{"euler yaw then pitch", RotateEuler(math.Pi/2, math.Pi/2, 0).MulDirection(Vec3{Z: 1}), Vec3{Y: 1}},
+		{"euler pitch right axis", RotateEuler(math.Pi/4, math.Pi/2, 0).MulDirection(Vec3{X: 1}), Vec3{Z: -1}},
+		{"translation", Translate(Vec3{4, 5, 6}).Mul(RotateX(1)).Translation(), Vec3{4, 5, 6}},
+	}
+	for _, test := range tests {
+		if !test.got.ApproxEqual(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, test.got, test.want)
+		}
+	}
+}
+
+func TestLookAt(t *testing.T) {
+	var tests = []struct {
+		name   string
+		eye    Vec3
+		target Vec3
+		up     Vec3
+		point  Vec3
+		want   Vec3
+	}{
+		{"forward", Vec3{}, Vec3{Z: 1}, Vec3{Y: 1}, Vec3{1, 2, 3}, Vec3{1, 2, 3}},
+		{"offset eye", Vec3{1, 2, 3}, Vec3{1, 2, 10}, Vec3{Y: 1}, Vec3{1, 2, 10}, Vec3{Z: 7}},
+		{"look right", Vec3{}, Vec3{X: 5}, Vec3{Y: 1}, Vec3{X: 2}, Vec3{Z: 2}},
+		{"look right keeps up", Vec3{}, Vec3{X: 5}, Vec3{Y: 1}, Vec3{Y: 2}, Vec3{Y: 2}},
+		{"look back", Vec3{}, Vec3{Z: -1}, Vec3{Y: 1}, Vec3{X: 1}, Vec3{X: -1}},
+		{"look down", Vec3{Y: 10}, Vec3{}, Vec3{Z: 1}, Vec3{}, Vec3{Z: 10}},
+		{"unnormalized up", Vec3{}, Vec3{Z: 1}, Vec3{Y: 100}, Vec3{X: 1}, Vec3{X: 1}},
+	}
+	for _, test := range tests {
+		var view, ok = LookAt(test.eye, test.target, test.up)
+		if !ok {
+			t.Errorf("%s: rejected", test.name)
+			continue
+		}
+		if got := view.MulPoint(test.point); !got.ApproxEqual(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+		if got := view.MulPoint(test.eye); !got.ApproxEqual(Vec3{}) {
+			t.Errorf("%s: eye maps to %v, want the origin", test.name, got)
+		}
+		if _, ok := view.Inverse(); !ok {
+			t.Errorf("%s: view matrix is singular", test.name)
+		}
+	}
+}
+
+func TestLookAtDegenerate(t *testing.T) {
+	var tests = []struct {
+		name   string
+		eye    Vec3
+		target Vec3
+		up     Vec3
+	}{
+		{"eye is target", Vec3{1, 2, 3}, Vec3{1, 2, 3}, Vec3{Y: 1}},
+		{"forward parallel to up", Vec3{}, Vec3{Y: 5}, Vec3{Y: 1}},
+		{"forward opposite to up", Vec3{Y: 5}, Vec3{}, Vec3{Y: 1}},
+		{"zero up", Vec3{}, Vec3{Z: 1}, Vec3{}},
+	}
+	for _, test := range tests {
+		var view, ok = LookAt(test.eye, test.target, test.up)
+		if ok {
+			t.Errorf("%s: accepted", test.name)
+		}
+		if view != Identity4() {
+			t.Errorf("%s: got %v, want the identity", test.name, view)
+		}
+	}
+}
+
+func TestPerspective(t *testing.T) {
+	var projection = Perspective(math.Pi/2, 2, 1, 100)
+	var tests = []struct {
+		name  string
+		point Vec3
+		want  Vec3
+	}{
+		{"near center", Vec3{Z: 1}, Vec3{}},
+		{"far center", Vec3{Z: 100}, Vec3{Z: 1}},
+		{"near top edge", Vec3{Y: 1, Z: 1}, Vec3{Y: 1}},
+		{"near right edge", Vec3{X: 2, Z: 1}, Vec3{X: 1}},
+		{"far bottom left", Vec3{X: -200, Y: -100, Z: 100}, Vec3{-1, -1, 1}},
+		{"middle", Vec3{X: 1, Y: 1, Z: 2}, Vec3{0.25, 0.5, 100.0 / 99 * 0.5}},
+	}
+	for _, test := range tests {
+		var got = projection.MulVec4(test.point.Vec4(1)).PerspectiveDivide()
+		if !got.ApproxEqual(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package vecmath
+
+import "math"
+
+// Quat is a rotation quaternion; W is the scalar part.
+type Quat struct {
+	X float64
+	Y float64
+	Z float64
+	W float64
+}
+
+func QuatIdentity() Quat {
+	return Quat{W: 1}
+}
+
+// QuatFromAxisAngle rotates by angle radians about axis, following the
+// same sense as RotateX, RotateY and RotateZ.
+func QuatFromAxisAngle(axis Vec3, angle float64) Quat {
+	var sin, cos = math.Sincos(angle / 2)
+	axis = axis.Normalize()
+	return Quat{X: axis.X * sin, Y: axis.Y * sin, Z: axis.Z * sin, W: cos}
+}
+
+// QuatFromEuler matches RotateEuler.
+func QuatFromEuler(pitch float64, yaw float64, roll float64) Quat {
+	return QuatFromAxisAngle(Vec3{Y: 1}, yaw).
+		Mul(QuatFromAxisAngle(Vec3{X: 1}, -pitch)).
+		Mul(QuatFromAxisAngle(Vec3{Z: 1}, roll))
+}
+
+// Mul combines two rotations; the result applies o first, then q.
+func (q Quat) Mul(o Quat) Quat {
+	return Quat{
+		X: q.W*o.X + q.X*o.W + q.Y*o.Z - q.Z*o.Y,
+		Y: q.W*o.Y - q.X*o.Z + q.Y*o.W + q.Z*o.X,
+		Z: q.W*o.Z + q.X*o.Y - q.Y*o.X + q.Z*o.W,
+		W: q.W*o.W - q.X*o.X - q.Y*o.Y - q.Z*o.Z,
+	}
+}
+
+func (q Quat) Conjugate() Quat {
+	return Quat{X: -q.X, Y: -q.Y, Z: -q.Z, W: q.W}
+}
+
+func (q Quat) Dot(o Quat) float64 {
+	return q.X*o.X + q.Y*o.Y + q.Z*o.Z + q.W*o.W
+}
+
+func (q Quat) Length() float64 {
+	return math.Sqrt(q.Dot(q))
+}
+
+// Normalize returns q scaled to length 1, or the identity if q is zero.
+func (q Quat) Normalize() Quat {
+	var length = q.Length()
+	if length < Epsilon {
+		return QuatIdentity()
+	}
+	return Quat{X: q.X / length, Y: q.Y / length, Z: q.Z / length, W: q.W / length}
+}
+
+func (q Quat) Inverse() Quat {
+	var lengthSq = q.Dot(q)
+	if lengthSq < Epsilon {
+		return QuatIdentity()
+	}
+	var c = q.Conjugate()
+	return Quat{X: c.X / lengthSq, Y: c.Y / lengthSq, Z: c.Z / lengthSq, W: c.W / lengthSq}
+}
+
+// Rotate rotates v by q, which must be normalized.
+func (q Quat) Rotate(v Vec3) Vec3 {
+	var u = Vec3{X: q.X, Y: q.Y, Z: q.Z}
+	var t = u.Cross(v).Scale(2)
+	return v.Add(t.Scale(q.W)).Add(u.Cross(t))
+}
+
+// Mat4 is the rotation matrix of q, which must be normalized.
+func (q Quat) Mat4() Mat4 {
+	var xx, yy, zz = q.X * q.X, q.Y * q.Y, q.Z * q.Z
+	var xy, xz, yz = q.X * q.Y, q.X * q.Z, q.Y * q.Z
+	var wx, wy, wz = q.W * q.X, q.W * q.Y, q.W * q.Z
+	return Mat4{
+		{1 - 2*(yy+zz), 2 * (xy - wz), 2 * (xz + wy), 0},
+		{2 * (xy + wz), 1 - 2*(xx+zz), 2 * (yz - wx), 0},
+		{2 * (xz - wy), 2 * (yz + wx), 1 - 2*(xx+yy), 0},
+		{0, 0, 0, 1},
+	}
+}
+
+// Slerp interpolates between q and o along the shorter arc at a constant
+// angular speed.
+func (q Quat) Slerp(o Quat, t float64) Quat {
+	var cos = q.Dot(o)
+	if cos < 0 {
+		o, cos = Quat{X: -o.X, Y: -o.Y, Z: -o.Z, W: -o.W}, -cos
+	}
+	if cos > 1-1e-6 {
+		return Quat{
+			X: q.X + (o.X-q.X)*t,
+			Y: q.Y + (o.Y-q.Y)*t,
+			Z: q.Z + (o.Z-q.Z)*t,
+			W: q.W + (o.W-q.W)*t,
+		}.Normalize()
+	}
+	var angle = math.Acos(cos)
+	var sin = math.Sin(angle)
+	var a, b = math.Sin((1-t)*angle) / sin, math.Sin(t*angle) / sin
+	return Quat{X: q.X*a + o.X*b, Y: q.Y*a + o.Y*b, Z: q.Z*a + o.Z*b, W: q.W*a + o.W*b}
+}
+
+func (q Quat) ApproxEqual(o Quat) bool {
+	return approxEqual(q.X, o.X) && approxEqual(q.Y, o.Y) && approxEqual(q.Z, o.Z) && approxEqual(q.W, o.W)
+}
@@ -0,0 +1,106 @@
+package vecmath
+
+import (
+	"math"
+	"testing"
+)
+
+func TestQuatFromEuler(t *testing.T) {
+	var tests = []struct {
+		pitch float64
+		yaw   float64
+		roll  float64
+	}{
+		{0, 0, 0},
+		{math.Pi / 2, 0, 0},
+		{0, math.Pi / 2, 0},
+		{0, 0, math.Pi / 2},
+		{0.3, -1.1, 0.7},
+		{-1.5, 2.9, -3},
+		{math.Pi, math.Pi, math.Pi},
+	}
+	for _, test := range tests {
+		var got = QuatFromEuler(test.pitch, test.yaw, test.roll).Mat4()
+		var want = RotateEuler(test.pitch, test.yaw, test.roll)
+		if !got.ApproxEqual(want) {
+			t.Errorf("QuatFromEuler(%v, %v, %v): got %v, want %v", test.pitch, test.yaw, test.roll, got, want)
+		}
+	}
+}
+
+func TestQuatRotate(t *testing.T) {
+	var tests = []struct {
+		name string
+		q    Quat
+		v    Vec3
+		want Vec3
+	}{
+		{"identity", QuatIdentity(), Vec3{1, 2, 3}, Vec3{1, 2, 3}},
+		{"x", QuatFromAxisAngle(Vec3{X: 1}, math.Pi/2), Vec3{Y: 1}, Vec3{Z: 1}},
+		{"y", QuatFromAxisAngle(Vec3{Y: 1}, math.Pi/2), Vec3{Z: 1}, Vec3{X: 1}},
+		{"z", QuatFromAxisAngle(Vec3{Z: 1}, math.Pi/2), Vec3{X: 1}, Vec3{Y: 1}},
+		{"unnormalized axis", QuatFromAxisAngle(Vec3{Z: 10}, math.Pi), Vec3{X: 1}, Vec3{X: -1}},
+		{"diagonal axis", QuatFromAxisAngle(Vec3{1, 1, 1}, 2*math.Pi/3), Vec3{X: 1}, Vec3{Y: 1}},
+		{"composed", QuatFromAxisAngle(Vec3{Y: 1}, math.Pi/2).Mul(QuatFromAxisAngle(Vec3{X: 1}, math.Pi/2)), Vec3{Y: 1}, Vec3{X: 1}},
+		{"inverse", QuatFromAxisAngle(Vec3{Z: 1}, math.Pi/2).Inverse(), Vec3{Y: 1}, Vec3{X: 1}},
+	}
+	for _, test := range tests {
+		if got := test.q.Rotate(test.v); !got.ApproxEqual(test.want) {
+			t.Errorf("%s: Rotate got %v, want %v", test.name, got, test.want)
+		}
+		if got := test.q.Mat4().MulDirection(test.v); !got.ApproxEqual(test.want) {
+			t.Errorf("%s: Mat4 got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestQuatNormalize(t *testing.T) {
+	var tests = []struct {
+		name string
+		q    Quat
+		want Quat
+	}{
+		{"unit", QuatIdentity(), QuatIdentity()},
+		{"scaled", Quat{W: 4}, QuatIdentity()},
+		{"mixed", Quat{X: 3, W: 4}, Quat{X: 0.6, W: 0.8}},
+		{"zero", Quat{}, QuatIdentity()},
+	}
+	for _, test := range tests {
+		if got := test.q.Normalize(); !got.ApproxEqual(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestQuatSlerp(t *testing.T) {
+	var zAxis = Vec3{Z: 1}
+	var quarter = QuatFromAxisAngle(zAxis, math.Pi/2)
+	var tests = []struct {
+		name string
+		from Quat
+		to   Quat
+		t    float64
+		want Quat
+	}{
+		{"start", QuatIdentity(), quarter, 0, QuatIdentity()},
+		{"end", QuatIdentity(), quarter, 1, quarter},
+		{"middle", QuatIdentity(), quarter, 0.5, QuatFromAxisAngle(zAxis, math.Pi/4)},
+		{"constant speed", QuatIdentity(), quarter, 0.25, QuatFromAxisAngle(zAxis, math.Pi/8)},
+		{"same", quarter, quarter, 0.5, quarter},
+		{"near parallel", QuatIdentity(), QuatFromAxisAngle(zAxis, 1e-7), 0.5, QuatFromAxisAngle(zAxis, 5e-8)},
+		{"near parallel end", QuatIdentity(), QuatFromAxisAngle(zAxis, 1e-7), 1, QuatFromAxisAngle(zAxis, 1e-7)},
+		{"negated target", quarter, Quat{X: -quarter.X, Y: -quarter.Y, Z: -quarter.Z, W: -quarter.W}, 0.5, quarter},
+		// 270 degrees is the same rotation as -90, so the shorter arc goes
+		// the other way round.
+		{"opposite hemisphere", QuatIdentity(), QuatFromAxisAngle(zAxis, 3*math.Pi/2), 0.5, QuatFromAxisAngle(zAxis, -math.Pi/4)},
+	}
+	for _, test := range tests {
+		var got = test.from.Slerp(test.to, test.t)
+		if !approxEqual(got.Length(), 1) {
+			t.Errorf("%s: result has length %v", test.name, got.Length())
+		}
+		if !got.Mat4().ApproxEqual(test.want.Mat4()) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
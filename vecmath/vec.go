@@ -0,0 +1,179 @@
+// Package vecmath has the vector, matrix and quaternion math behind the 3D
+// types. It uses flux's 3D conventions: +X is right, +Y is up and +Z is
+// forward, and matrices multiply column vectors.
+package vecmath
+
+import "math"
+
+// Epsilon is the tolerance used when comparing values and detecting
+// singular matrices.
+var Epsilon = 1e-9
+
+type Vec2 struct {
+	X float64
+	Y float64
+}
+
+func (v Vec2) Add(o Vec2) Vec2 {
+	return Vec2{X: v.X + o.X, Y: v.Y + o.Y}
+}
+
+func (v Vec2) Sub(o Vec2) Vec2 {
+	return Vec2{X: v.X - o.X, Y: v.Y - o.Y}
+}
+
+func (v Vec2) Scale(s float64) Vec2 {
+	return Vec2{X: v.X * s, Y: v.Y * s}
+}
+
+func (v Vec2) Dot(o Vec2) float64 {
+	return v.X*o.X + v.Y*o.Y
+}
+
+// Cross is the Z component of the cross product of v and o.
+func (v Vec2) Cross(o Vec2) float64 {
+	return v.X*o.Y - v.Y*o.X
+}
+
+func (v Vec2) Length() float64 {
+	return math.Hypot(v.X, v.Y)
+}
+
+// Normalize returns v scaled to length 1, or the zero vector if v is zero.
+func (v Vec2) Normalize() Vec2 {
+	var length = v.Length()
+	if length < Epsilon {
+		return Vec2{}
+	}
+	return v.Scale(1 / length)
+}
+
+func (v Vec2) Lerp(o Vec2, t float64) Vec2 {
+	return v.Add(o.Sub(v).Scale(t))
+}
+
+func (v Vec2) ApproxEqual(o Vec2) bool {
+	return approxEqual(v.X, o.X) && approxEqual(v.Y, o.Y)
+}
+
+type Vec3 struct {
+	X float64
+	Y float64
+	Z float64
+}
+
+func (v Vec3) Add(o Vec3) Vec3 {
+	return Vec3{X: v.X + o.X, Y: v.Y + o.Y, Z: v.Z + o.Z}
+}
+
+func (v Vec3) Sub(o Vec3) Vec3 {
+	return Vec3{X: v.X - o.X, Y: v.Y - o.Y, Z: v.Z - o.Z}
+}
+
+func (v Vec3) Scale(s float64) Vec3 {
+	return Vec3{X: v.X * s, Y: v.Y * s, Z: v.Z * s}
+}
+
+// Mul multiplies v and o component by component.
+func (v Vec3) Mul(o Vec3) Vec3 {
+	return Vec3{X: v.X * o.X, Y: v.Y * o.Y, Z: v.Z * o.Z}
+}
+
+func (v Vec3) Negate() Vec3 {
+	return Vec3{X: -v.X, Y: -v.Y, Z: -v.Z}
+}
+
+func (v Vec3) Dot(o Vec3) float64 {
+	return v.X*o.X + v.Y*o.Y + v.Z*o.Z
+}
+
+func (v Vec3) Cross(o Vec3) Vec3 {
+	return Vec3{X: v.Y*o.Z - v.Z*o.Y, Y: v.Z*o.X - v.X*o.Z, Z: v.X*o.Y - v.Y*o.X}
+}
+
+func (v Vec3) LengthSq() float64 {
+	return v.Dot(v)
+}
+
+func (v Vec3) Length() float64 {
+	return math.Sqrt(v.LengthSq())
+}
+
+// Normalize returns v scaled to length 1, or the zero vector if v is zero.
+func (v Vec3) Normalize() Vec3 {
+	var length = v.Length()
+	if length < Epsilon {
+		return Vec3{}
+	}
+	return v.Scale(1 / length)
+}
+
+func (v Vec3) Lerp(o Vec3, t float64) Vec3 {
+	return v.Add(o.Sub(v).Scale(t))
+}
+
+func (v Vec3) Vec4(w float64) Vec4 {
+	return Vec4{X: v.X, Y: v.Y, Z: v.Z, W: w}
+}
+
+func (v Vec3) ApproxEqual(o Vec3) bool {
+	return approxEqual(v.X, o.X) && approxEqual(v.Y, o.Y) && approxEqual(v.Z, o.Z)
+}
+
+type Vec4 struct {
+	X float64
+	Y float64
+	Z float64
+	W float64
+}
+
+func (v Vec4) Add(o Vec4) Vec4 {
+	return Vec4{X: v.X + o.X, Y: v.Y + o.Y, Z: v.Z + o.Z, W: v.W + o.W}
+}
+
+func (v Vec4) Sub(o Vec4) Vec4 {
+	return Vec4{X: v.X - o.X, Y: v.Y - o.Y, Z: v.Z - o.Z, W: v.W - o.W}
+}
+
+func (v Vec4) Scale(s float64) Vec4 {
+	return Vec4{X: v.X * s, Y: v.Y * s, Z: v.Z * s, W: v.W * s}
+}
+
+func (v Vec4) Dot(o Vec4) float64 {
+	return v.X*o.X + v.Y*o.Y + v.Z*o.Z + v.W*o.W
+}
+
+func (v Vec4) Length() float64 {
+	return math.Sqrt(v.Dot(v))
+}
+
+// Normalize returns v scaled to length 1, or the zero vector if v is zero.
+func (v Vec4) Normalize() Vec4 {
+	var length = v.Length()
+	if length < Epsilon {
+		return Vec4{}
+	}
+	return v.Scale(1 / length)
+}
+
+func (v Vec4) Lerp(o Vec4, t float64) Vec4 {
+	return v.Add(o.Sub(v).Scale(t))
+}
+
+// Vec3 drops W.
+func (v Vec4) Vec3() Vec3 {
+	return Vec3{X: v.X, Y: v.Y, Z: v.Z}
+}
+
+// PerspectiveDivide divides X, Y and Z by W.
+func (v Vec4) PerspectiveDivide() Vec3 {
+	return Vec3{X: v.X / v.W, Y: v.Y / v.W, Z: v.Z / v.W}
+}
+
+func (v Vec4) ApproxEqual(o Vec4) bool {
+	return approxEqual(v.X, o.X) && approxEqual(v.Y, o.Y) && approxEqual(v.Z, o.Z) && approxEqual(v.W, o.W)
+}
+
+func approxEqual(a float64, b float64) bool {
+	return math.Abs(a-b) <= Epsilon*max(1, math.Abs(a), math.Abs(b))
+}
@@ -0,0 +1,76 @@
+package vecmath
+
+import (
+	"math"
+	"testing"
+)
+
+func TestVec3(t *testing.T) {
+	var tests = []struct {
+		name string
+		got  Vec3
+		want Vec3
+	}{
+		{"add", Vec3{1, 2, 3}.Add(Vec3{4, 5, 6}), Vec3{5, 7, 9}},
+		{"sub", Vec3{1, 2, 3}.Sub(Vec3{4, 5, 6}), Vec3{-3, -3, -3}},
+		{"scale", Vec3{1, -2, 3}.Scale(2), Vec3{2, -4, 6}},
+		{"mul", Vec3{1, 2, 3}.Mul(Vec3{4, 5, 6}), Vec3{4, 10, 18}},
+		{"negate", Vec3{1, -2, 3}.Negate(), Vec3{-1, 2, -3}},
+		{"cross x y", Vec3{X: 1}.Cross(Vec3{Y: 1}), Vec3{Z: 1}},
+		{"cross y z", Vec3{Y: 1}.Cross(Vec3{Z: 1}), Vec3{X: 1}},
+		{"cross z x", Vec3{Z: 1}.Cross(Vec3{X: 1}), Vec3{Y: 1}},
+		{"normalize", Vec3{3, 0, 4}.Normalize(), Vec3{0.6, 0, 0.8}},
+		{"normalize zero", Vec3{}.Normalize(), Vec3{}},
+		{"lerp", Vec3{0, 10, -4}.Lerp(Vec3{10, 20, 4}, 0.25), Vec3{2.5, 12.5, -2}},
+		{"perspective divide", Vec4{2, 4, 6, 2}.PerspectiveDivide(), Vec3{1, 2, 3}},
+		{"vec4 vec3", Vec3{1, 2, 3}.Vec4(1).Vec3(), Vec3{1, 2, 3}},
+	}
+	for _, test := range tests {
+		if !test.got.ApproxEqual(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, test.got, test.want)
+		}
+	}
+}
+
+func TestVecScalars(t *testing.T) {
+	var tests = []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"vec2 dot", Vec2{1, 2}.Dot(Vec2{3, 4}), 11},
+		{"vec2 cross", Vec2{1, 0}.Cross(Vec2{0, 1}), 1},
+		{"vec2 length", Vec2{3, 4}.Length(), 5},
+		{"vec3 dot", Vec3{1, 2, 3}.Dot(Vec3{4, -5, 6}), 12},
+		{"vec3 length", Vec3{2, 3, 6}.Length(), 7},
+		{"vec3 length squared", Vec3{2, 3, 6}.LengthSq(), 49},
+		{"vec4 dot", Vec4{1, 2, 3, 4}.Dot(Vec4{1, 1, 1, 1}), 10},
+		{"vec4 length", Vec4{1, 1, 1, 1}.Length(), 2},
+		{"normalized length", Vec3{1e-3, 7e5, -3}.Normalize().Length(), 1},
+	}
+	for _, test := range tests {
+		if !approxEqual(test.got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, test.got, test.want)
+		}
+	}
+}
+
+func TestApproxEqual(t *testing.T) {
+	var tests = []struct {
+		a    float64
+		b    float64
+		want bool
+	}{
+		{1, 1, true},
+		{0, Epsilon / 2, true},
+		{0, Epsilon * 2, false},
+		{1e12, 1e12 + 1, true},
+		{1e12, 1e12 + 1e4, false},
+		{math.Pi, 3.14159, false},
+	}
+	for _, test := range tests {
+		if got := approxEqual(test.a, test.b); got != test.want {
+			t.Errorf("approxEqual(%v, %v) = %v, want %v", test.a, test.b, got, test.want)
+		}
+	}
+}